@@ -0,0 +1,242 @@
+/*
+Package integration This file provides a plan-to-apply consistency test. It applies exactly the plan that was
+validated by a unit test and verifies that the resulting state matches what the plan promised, which catches
+provider inconsistencies and unexpected drift of computed values.
+*/
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+	"github.com/microsoft/terratest-abstraction/unit"
+)
+
+// PlanApplyTestFixture Holds metadata required to verify that applying a saved plan produces the planned values
+type PlanApplyTestFixture struct {
+	UnitTestFixture *unit.UnitTestFixture // Unit test fixture that describes the plan and how it is validated
+	AllowedDrift    []string              // Attribute paths, e.g. `azurerm_resource_group.rg.tags`, that may differ from the plan
+	SkipDestroy     bool                  // Keep the deployed resources once the test completes
+}
+
+// RunPlanApplyTests Applies exactly the plan that is validated by the unit test fixture and verifies that the
+// resulting state is consistent with it. The following actions are coordinated:
+//   - Run `terraform init` and optionally select (or create) the workspace of the unit test fixture
+//   - Verify the variables and run `terraform plan` and every validation of `unit.PlanAndValidate`, including the
+//     optional validation of the expectations against the provider schemas
+//   - Run `terraform apply` with the saved plan file
+//   - Validate that every attribute value that was known in the plan matches the resulting state
+//   - Validate that running `terraform plan` again does not find any changes
+//   - Run `terraform destroy` and verify that nothing was left behind, unless `SkipDestroy` is set
+//
+// A prior state cannot be applied on top of, so fixtures that set `PriorState` or `PriorStateFile` are rejected
+func RunPlanApplyTests(fixture *PlanApplyTestFixture) {
+	unitFixture := fixture.UnitTestFixture
+	goTest := unitFixture.GoTest
+	options := unitFixture.TfOptions
+
+	if unitFixture.PriorStateFile != "" || len(unitFixture.PriorState) > 0 {
+		goTest.Fatal("Plan could not be applied because the unit test fixture describes a prior state, " +
+			"which only exists for planning")
+	}
+
+	terraform.Init(goTest, options)
+
+	startingWorkspaceName := ""
+	if unitFixture.Workspace != "" {
+		startingWorkspaceName = terraform.RunTerraformCommand(
+			goTest,
+			options,
+			terraform.FormatArgs(options, "workspace", "show")...)
+		terraform.WorkspaceSelectOrNew(goTest, options, unitFixture.Workspace)
+	}
+	if !fixture.SkipDestroy {
+		defer destroyAndVerify(goTest, options, unitFixture.Workspace, startingWorkspaceName)
+	} else if unitFixture.Workspace != "" {
+		defer terraform.WorkspaceSelectOrNew(goTest, options, startingWorkspaceName)
+	}
+
+	tfPlanFilePath := filepath.FromSlash(fmt.Sprintf("%s/%s.plan", os.TempDir(), random.UniqueId()))
+	defer os.Remove(tfPlanFilePath)
+
+	plan := unit.PlanAndValidate(unitFixture, tfPlanFilePath)
+	if plan == nil {
+		goTest.Fatal("Plan could not be applied because `terraform plan` failed")
+	}
+
+	applyOptions, err := options.Clone()
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	applyOptions.PlanFilePath = tfPlanFilePath
+	terraform.Apply(goTest, applyOptions)
+
+	state := showState(goTest, options)
+
+	allowed := make(map[string]bool)
+	for _, path := range fixture.AllowedDrift {
+		allowed[path] = true
+	}
+
+	goTest.Run("Terraform Apply Matches Plan", func(t *testing.T) {
+		if differences := plannedValueDifferences(*plan, *state, allowed); len(differences) > 0 {
+			t.Fatalf("State unexpectedly differs from the applied plan:\n\t- %s", strings.Join(differences, "\n\t- "))
+		}
+	})
+
+	goTest.Run("Terraform Plan Is Empty After Apply", func(t *testing.T) {
+		// `terraform plan -detailed-exitcode` exits with 2 if there are changes
+		if exitCode := terraform.PlanExitCode(t, options); exitCode != 0 {
+			t.Fatalf("Plan unexpectedly found changes right after applying, exit code was %d", exitCode)
+		}
+	})
+}
+
+// returns a description of every attribute of a managed resource whose value in the state differs from the value
+// that was known in the plan
+func plannedValueDifferences(plan tfjson.Plan, state tfjson.State, allowed map[string]bool) []string {
+	resources := make(map[string]*tfjson.StateResource)
+	if state.Values != nil {
+		for _, resource := range tfstate.ModuleResources(state.Values.RootModule) {
+			resources[resource.Address] = resource
+		}
+	}
+
+	var differences []string
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil || change.Mode != tfjson.ManagedResourceMode || change.Change.Actions.Delete() {
+			continue
+		}
+
+		resource, isFound := resources[change.Address]
+		if !isFound {
+			differences = append(differences, fmt.Sprintf("'%s' was planned but is missing from the state", change.Address))
+			continue
+		}
+
+		var actual interface{} = resource.AttributeValues
+		differences = append(differences, knownValueDifferences(
+			change.Change.After, change.Change.AfterUnknown, actual, change.Address, allowed)...)
+	}
+	return differences
+}
+
+// returns a description of every value that was known in the plan but differs from the actual value. The structure
+// of `unknown` mirrors the planned value and marks values that were unknown until apply with `true`
+func knownValueDifferences(planned interface{}, unknown interface{}, actual interface{}, traversalPath string, allowed map[string]bool) []string {
+	if allowed[traversalPath] || unknown == true {
+		return nil
+	}
+	mismatch := []string{fmt.Sprintf("'%s' was planned as '%v' but is '%v'", traversalPath, planned, actual)}
+
+	switch typedPlanned := planned.(type) {
+	case map[string]interface{}:
+		typedActual, ok := actual.(map[string]interface{})
+		if !ok {
+			return mismatch
+		}
+		unknownValues, _ := unknown.(map[string]interface{})
+
+		keys := make([]string, 0, len(typedPlanned))
+		for key := range typedPlanned {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var differences []string
+		for _, key := range keys {
+			differences = append(differences, knownValueDifferences(
+				typedPlanned[key], unknownValues[key], typedActual[key], traversalPath+"."+key, allowed)...)
+		}
+		return differences
+	case []interface{}:
+		typedActual, ok := actual.([]interface{})
+		if !ok || len(typedActual) != len(typedPlanned) {
+			return mismatch
+		}
+		unknownValues, _ := unknown.([]interface{})
+
+		var differences []string
+		for i := range typedPlanned {
+			differences = append(differences, knownValueDifferences(
+				typedPlanned[i], elementAt(unknownValues, i), typedActual[i], fmt.Sprintf("%s[%d]", traversalPath, i), allowed)...)
+		}
+		// terraform orders the objects of a set by their hash, which changes once their computed values are known
+		if len(differences) > 0 && isObjectList(typedPlanned) &&
+			matchesInAnyOrder(typedPlanned, unknownValues, typedActual, traversalPath, allowed) {
+			return nil
+		}
+		return differences
+	}
+
+	if !reflect.DeepEqual(planned, actual) {
+		return mismatch
+	}
+	return nil
+}
+
+// returns true if every planned object matches a different actual object, regardless of their order. This is a
+// bipartite matching, because an object whose identifying values were unknown in the plan may match several objects
+func matchesInAnyOrder(planned []interface{}, unknown []interface{}, actual []interface{}, traversalPath string, allowed map[string]bool) bool {
+	candidates := make([][]int, len(planned))
+	for i := range planned {
+		for j := range actual {
+			if len(knownValueDifferences(planned[i], elementAt(unknown, i), actual[j], fmt.Sprintf("%s[%d]", traversalPath, i), allowed)) == 0 {
+				candidates[i] = append(candidates[i], j)
+			}
+		}
+	}
+
+	// index of the planned object that each actual object is matched with, if any
+	matchedWith := make([]int, len(actual))
+	for j := range matchedWith {
+		matchedWith[j] = -1
+	}
+	var match func(i int, visited []bool) bool
+	match = func(i int, visited []bool) bool {
+		for _, j := range candidates[i] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if matchedWith[j] < 0 || match(matchedWith[j], visited) {
+				matchedWith[j] = i
+				return true
+			}
+		}
+		return false
+	}
+	for i := range planned {
+		if !match(i, make([]bool, len(actual))) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns true if the list holds objects, such as the blocks of a set
+func isObjectList(list []interface{}) bool {
+	for _, element := range list {
+		if _, isObject := element.(map[string]interface{}); !isObject {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// returns the element at the index, or nil if the list is shorter
+func elementAt(list []interface{}, index int) interface{} {
+	if index < len(list) {
+		return list[index]
+	}
+	return nil
+}
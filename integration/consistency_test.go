@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+)
+
+const appliedPlanJSON = `{
+	"format_version": "0.2",
+	"resource_changes": [{
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"type": "azurerm_resource_group",
+		"name": "rg",
+		"change": {
+			"actions": ["create"],
+			"after": {"name": "MyTestResourceGroup", "location": "centralus", "tags": {"env": "dev"}, "id": null},
+			"after_unknown": {"id": true, "tags": {}}
+		}
+	}, {
+		"address": "azurerm_virtual_network.vnet",
+		"mode": "managed",
+		"type": "azurerm_virtual_network",
+		"name": "vnet",
+		"change": {
+			"actions": ["create"],
+			"after": {"address_space": ["10.0.0.0/16"], "subnet": [{"name": "a", "id": null}], "guid": null},
+			"after_unknown": {"guid": true, "subnet": [{"id": true}]}
+		}
+	}, {
+		"address": "data.azurerm_client_config.current",
+		"mode": "data",
+		"type": "azurerm_client_config",
+		"name": "current",
+		"change": {"actions": ["read"], "after": {"tenant_id": "0000"}}
+	}, {
+		"address": "azurerm_network_security_group.old",
+		"mode": "managed",
+		"type": "azurerm_network_security_group",
+		"name": "old",
+		"change": {"actions": ["delete"], "before": {"name": "old"}}
+	}]
+}`
+
+const appliedStateJSON = `{
+	"format_version": "0.2",
+	"values": {
+		"root_module": {
+			"resources": [{
+				"address": "azurerm_resource_group.rg",
+				"mode": "managed",
+				"values": {"name": "MyTestResourceGroup", "location": "centralus", "tags": {"env": "dev"}, "id": "/rg"}
+			}, {
+				"address": "azurerm_virtual_network.vnet",
+				"mode": "managed",
+				"values": {"address_space": ["10.0.0.0/16"], "subnet": [{"name": "a", "id": "/subnet"}], "guid": "1234"}
+			}]
+		}
+	}
+}`
+
+func TestPlannedValuesMatchState(t *testing.T) {
+	plan, state := jsonToPlan(t, appliedPlanJSON), jsonToState(t, appliedStateJSON)
+
+	if differences := plannedValueDifferences(plan, state, nil); len(differences) > 0 {
+		t.Fatalf("Expected no differences but got %v", differences)
+	}
+}
+
+func TestPlannedValueDifferences(t *testing.T) {
+	driftedStateJSON := strings.NewReplacer(
+		`"location": "centralus"`, `"location": "eastus"`,
+		`"tags": {"env": "dev"}`, `"tags": {"env": "dev", "owner": "policy"}`,
+		`"address_space": ["10.0.0.0/16"]`, `"address_space": ["10.0.0.0/16", "10.1.0.0/16"]`,
+	).Replace(appliedStateJSON)
+	plan, state := jsonToPlan(t, appliedPlanJSON), jsonToState(t, driftedStateJSON)
+
+	differences := plannedValueDifferences(plan, state, nil)
+	expected := []string{"'azurerm_resource_group.rg.location'", "'azurerm_virtual_network.vnet.address_space'"}
+	if len(differences) != len(expected) {
+		t.Fatalf("Expected %d differences but got %v", len(expected), differences)
+	}
+	for i := range expected {
+		if !strings.HasPrefix(differences[i], expected[i]) {
+			t.Errorf("Expected difference '%s' to be about %s", differences[i], expected[i])
+		}
+	}
+
+	allowed := map[string]bool{
+		"azurerm_resource_group.rg.location":         true,
+		"azurerm_virtual_network.vnet.address_space": true,
+	}
+	if differences := plannedValueDifferences(plan, state, allowed); len(differences) > 0 {
+		t.Fatalf("Expected allowed drift to be ignored but got %v", differences)
+	}
+}
+
+func TestPlannedResourceMissingFromState(t *testing.T) {
+	plan, state := jsonToPlan(t, appliedPlanJSON), jsonToState(t, `{"format_version": "0.2"}`)
+
+	differences := plannedValueDifferences(plan, state, nil)
+	if len(differences) != 2 || !strings.Contains(differences[0], "missing from the state") {
+		t.Fatalf("Expected 2 missing resources but got %v", differences)
+	}
+}
+
+func TestPlannedSetBlocksMatchInAnyOrder(t *testing.T) {
+	planJSON := strings.Replace(appliedPlanJSON,
+		`"subnet": [{"name": "a", "id": null}], "guid": null},
+			"after_unknown": {"guid": true, "subnet": [{"id": true}]}`,
+		`"subnet": [{"name": "a", "id": null}, {"name": "b", "id": null}], "guid": null},
+			"after_unknown": {"guid": true, "subnet": [{"id": true}, {"id": true}]}`, 1)
+	reorderedStateJSON := strings.Replace(appliedStateJSON,
+		`"subnet": [{"name": "a", "id": "/subnet"}]`, `"subnet": [{"name": "b", "id": "/b"}, {"name": "a", "id": "/a"}]`, 1)
+	plan, state := jsonToPlan(t, planJSON), jsonToState(t, reorderedStateJSON)
+
+	if differences := plannedValueDifferences(plan, state, nil); len(differences) > 0 {
+		t.Fatalf("Expected reordered set blocks to match but got %v", differences)
+	}
+
+	renamedStateJSON := strings.Replace(reorderedStateJSON, `"name": "b"`, `"name": "c"`, 1)
+	plan, state = jsonToPlan(t, planJSON), jsonToState(t, renamedStateJSON)
+	if differences := plannedValueDifferences(plan, state, nil); len(differences) == 0 {
+		t.Fatal("Expected a renamed set block to be reported")
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Package integration This file provides utilities that decode terraform output into user-defined structs, so that
+validations can work with typed values instead of repeating type assertions like `output["vnet_name"].(string)`.
+*/
+package integration
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// outputTagName Name of the struct tag that maps a field to an output (or to a key of a nested object). The tag value
+// is the output name, optionally followed by `,optional` for outputs that may be missing. Fields without the tag, or
+// with the tag value `-`, are ignored
+const outputTagName = "output"
+
+// DecodeOutput Decodes the terraform output into the struct pointed to by target. Numbers, lists, maps and nested
+// objects are converted to the types of the struct fields. For example:
+//
+//	type vnetOutput struct {
+//		Name          string            `output:"vnet_name"`
+//		SubnetCount   int               `output:"subnet_count"`
+//		AddressSpaces []string          `output:"address_space"`
+//		Tags          map[string]string `output:"tags,optional"`
+//	}
+//
+//	var vnet vnetOutput
+//	integration.DecodeOutput(t, output, &vnet)
+//
+// Every missing or mistyped output is reported before the test is stopped.
+func DecodeOutput(t testing.TB, output TerraformOutput, target interface{}) {
+	if errs := decodeOutput(output, target); len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = "\n\t- " + err.Error()
+		}
+		t.Fatalf("Output could not be decoded into %T:%s", target, strings.Join(messages, ""))
+	}
+}
+
+// decodes the output into the target and returns every error that was encountered
+func decodeOutput(output TerraformOutput, target interface{}) []error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return []error{fmt.Errorf("Decode target must be a non-nil pointer to a struct but was %T", target)}
+	}
+	return decodeObject(map[string]interface{}(output), targetValue.Elem(), "")
+}
+
+// decodes an object into the tagged fields of a struct
+func decodeObject(object map[string]interface{}, target reflect.Value, traversalPath string) []error {
+	var errs []error
+	targetType := target.Type()
+
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		tag, hasTag := field.Tag.Lookup(outputTagName)
+		if !hasTag || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		name, options := tag, ""
+		if separator := strings.Index(tag, ","); separator >= 0 {
+			name, options = tag[:separator], tag[separator+1:]
+		}
+		currentTraversalPath := joinTraversalPath(traversalPath, name)
+
+		value, isFound := object[name]
+		if !isFound {
+			if options != "optional" {
+				errs = append(errs, fmt.Errorf("Unexpectedly could not find key '%s' at node '%s'", name, currentTraversalPath))
+			}
+			continue
+		}
+		errs = append(errs, decodeValue(value, target.Field(i), currentTraversalPath)...)
+	}
+
+	return errs
+}
+
+// decodes a generic value, as parsed from JSON, into the target
+func decodeValue(value interface{}, target reflect.Value, traversalPath string) []error {
+	if value == nil {
+		// null values leave the target at its zero value
+		return nil
+	}
+	mistyped := []error{fmt.Errorf("Unexpectedly found type '%T' that cannot be decoded into '%s' at node %s", value, target.Type(), traversalPath)}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		// only empty interfaces, or interfaces that the generic value happens to implement, can hold it
+		if !reflect.TypeOf(value).AssignableTo(target.Type()) {
+			return mistyped
+		}
+		target.Set(reflect.ValueOf(value))
+	case reflect.Ptr:
+		element := reflect.New(target.Type().Elem())
+		if errs := decodeValue(value, element.Elem(), traversalPath); len(errs) > 0 {
+			return errs
+		}
+		target.Set(element)
+	case reflect.String:
+		typedValue, ok := value.(string)
+		if !ok {
+			return mistyped
+		}
+		target.SetString(typedValue)
+	case reflect.Bool:
+		typedValue, ok := value.(bool)
+		if !ok {
+			return mistyped
+		}
+		target.SetBool(typedValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, ok := asFloat64(value)
+		if !ok || number != math.Trunc(number) || target.OverflowInt(int64(number)) {
+			return mistyped
+		}
+		target.SetInt(int64(number))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, ok := asFloat64(value)
+		if !ok || number < 0 || number != math.Trunc(number) || target.OverflowUint(uint64(number)) {
+			return mistyped
+		}
+		target.SetUint(uint64(number))
+	case reflect.Float32, reflect.Float64:
+		number, ok := asFloat64(value)
+		if !ok {
+			return mistyped
+		}
+		target.SetFloat(number)
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return mistyped
+		}
+		return decodeList(list, target, traversalPath)
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok || target.Type().Key().Kind() != reflect.String {
+			return mistyped
+		}
+		return decodeMap(object, target, traversalPath)
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return mistyped
+		}
+		return decodeObject(object, target, traversalPath)
+	default:
+		return mistyped
+	}
+
+	return nil
+}
+
+// decodes a list into a slice
+func decodeList(list []interface{}, target reflect.Value, traversalPath string) []error {
+	var errs []error
+	slice := reflect.MakeSlice(target.Type(), len(list), len(list))
+	for i, item := range list {
+		errs = append(errs, decodeValue(item, slice.Index(i), fmt.Sprintf("%s[%d]", traversalPath, i))...)
+	}
+	target.Set(slice)
+	return errs
+}
+
+// decodes an object into a map with string keys
+func decodeMap(object map[string]interface{}, target reflect.Value, traversalPath string) []error {
+	var errs []error
+	mapValue := reflect.MakeMapWithSize(target.Type(), len(object))
+	for key, item := range object {
+		element := reflect.New(target.Type().Elem()).Elem()
+		errs = append(errs, decodeValue(item, element, joinTraversalPath(traversalPath, key))...)
+		mapValue.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), element)
+	}
+	target.Set(mapValue)
+	return errs
+}
+
+// returns the numeric value of JSON numbers as well as of integers supplied in expected outputs
+func asFloat64(value interface{}) (float64, bool) {
+	switch typedValue := value.(type) {
+	case float64:
+		return typedValue, true
+	case float32:
+		return float64(typedValue), true
+	case int:
+		return float64(typedValue), true
+	case int64:
+		return float64(typedValue), true
+	}
+	return 0, false
+}
+
+// appends a key to a traversal path
+func joinTraversalPath(traversalPath string, key string) string {
+	if traversalPath == "" {
+		return key
+	}
+	return traversalPath + "." + key
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type subnetOutput struct {
+	Name          string `output:"name"`
+	AddressPrefix string `output:"address_prefix"`
+}
+
+type vnetOutput struct {
+	Name          string            `output:"vnet_name"`
+	SubnetCount   int               `output:"subnet_count"`
+	Ratio         float64           `output:"ratio"`
+	Enabled       bool              `output:"enabled"`
+	AddressSpaces []string          `output:"address_space"`
+	Tags          map[string]string `output:"tags"`
+	Subnets       []subnetOutput    `output:"subnets"`
+	Gateway       *subnetOutput     `output:"gateway,optional"`
+	DNSServers    []string          `output:"dns_servers,optional"`
+	Raw           interface{}       `output:"raw"`
+	Ignored       string
+	Skipped       string `output:"-"`
+}
+
+const vnetOutputJSON = `{
+	"vnet_name": "virtualNetwork1",
+	"subnet_count": 2,
+	"ratio": 0.5,
+	"enabled": true,
+	"address_space": ["10.0.0.0/16"],
+	"tags": {"environment": "production"},
+	"subnets": [
+		{"name": "MyTestSubnet1", "address_prefix": "10.0.1.0/24"},
+		{"name": "MyTestSubnet2", "address_prefix": "10.0.3.0/24"}
+	],
+	"gateway": {"name": "GatewaySubnet", "address_prefix": "10.0.255.0/27"},
+	"raw": {"anything": [1, "two"]},
+	"Ignored": "value",
+	"-": "value"
+}`
+
+func jsonToOutput(t *testing.T, jsonStr string) TerraformOutput {
+	var output TerraformOutput
+	if err := json.Unmarshal([]byte(jsonStr), &output); err != nil {
+		t.Fatalf("Unable to parse JSON `%s`. Error = `%s`", jsonStr, err)
+	}
+	return output
+}
+
+func TestDecodeOutput(t *testing.T) {
+	var vnet vnetOutput
+	DecodeOutput(t, jsonToOutput(t, vnetOutputJSON), &vnet)
+
+	if vnet.Name != "virtualNetwork1" || vnet.SubnetCount != 2 || vnet.Ratio != 0.5 || !vnet.Enabled {
+		t.Errorf("Scalar outputs were not decoded correctly: %+v", vnet)
+	}
+	if len(vnet.AddressSpaces) != 1 || vnet.AddressSpaces[0] != "10.0.0.0/16" {
+		t.Errorf("List output was not decoded correctly: %v", vnet.AddressSpaces)
+	}
+	if vnet.Tags["environment"] != "production" {
+		t.Errorf("Map output was not decoded correctly: %v", vnet.Tags)
+	}
+	if len(vnet.Subnets) != 2 || vnet.Subnets[1].AddressPrefix != "10.0.3.0/24" {
+		t.Errorf("List of objects output was not decoded correctly: %+v", vnet.Subnets)
+	}
+	if vnet.Gateway == nil || vnet.Gateway.Name != "GatewaySubnet" {
+		t.Errorf("Object output was not decoded correctly: %+v", vnet.Gateway)
+	}
+	if vnet.DNSServers != nil || vnet.Ignored != "" || vnet.Skipped != "" {
+		t.Errorf("Optional, untagged or skipped fields were unexpectedly set: %+v", vnet)
+	}
+	if _, ok := vnet.Raw.(map[string]interface{}); !ok {
+		t.Errorf("Raw output was not decoded correctly: %v", vnet.Raw)
+	}
+}
+
+func TestDecodeOutputReportsEveryError(t *testing.T) {
+	output := jsonToOutput(t, `{
+		"vnet_name": 1,
+		"subnet_count": 2.5,
+		"ratio": 0.5,
+		"enabled": "true",
+		"address_space": ["10.0.0.0/16", 5],
+		"tags": {"environment": "production"},
+		"subnets": [{"name": "MyTestSubnet1"}]
+	}`)
+
+	var vnet vnetOutput
+	errs := decodeOutput(output, &vnet)
+
+	expectedNodes := []string{"vnet_name", "subnet_count", "enabled", "address_space[1]", "subnets[0].address_prefix", "'raw'"}
+	if len(errs) != len(expectedNodes) {
+		t.Fatalf("Expected %d errors but got %d: %v", len(expectedNodes), len(errs), errs)
+	}
+	for i, expectedNode := range expectedNodes {
+		if !strings.Contains(errs[i].Error(), expectedNode) {
+			t.Errorf("Expected error '%s' to mention '%s'", errs[i], expectedNode)
+		}
+	}
+}
+
+func TestDecodeOutputRejectsInvalidTargets(t *testing.T) {
+	var vnet vnetOutput
+	for _, target := range []interface{}{vnet, &[]string{}, (*vnetOutput)(nil), nil} {
+		if errs := decodeOutput(TerraformOutput{}, target); len(errs) != 1 {
+			t.Errorf("Expected target %T to be rejected", target)
+		}
+	}
+}
+
+func TestDecodeOutputRejectsUnassignableInterfaces(t *testing.T) {
+	var target struct {
+		Name fmt.Stringer `output:"name"`
+	}
+	errs := decodeOutput(TerraformOutput{"name": "vnet"}, &target)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "fmt.Stringer") {
+		t.Fatalf("Expected a single error for the 'fmt.Stringer' field but got %v", errs)
+	}
+}
@@ -0,0 +1,24 @@
+package integration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+func jsonToPlan(t *testing.T, jsonStr string) tfjson.Plan {
+	var plan tfjson.Plan
+	if err := json.Unmarshal([]byte(jsonStr), &plan); err != nil {
+		t.Fatalf("Unable to parse plan JSON. Error = `%s`", err)
+	}
+	return plan
+}
+
+func jsonToState(t *testing.T, jsonStr string) tfjson.State {
+	var state tfjson.State
+	if err := json.Unmarshal([]byte(jsonStr), &state); err != nil {
+		t.Fatalf("Unable to parse state JSON. Error = `%s`", err)
+	}
+	return state
+}
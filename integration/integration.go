@@ -11,6 +11,10 @@ import (
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+	"github.com/microsoft/terratest-abstraction/internal/variables"
+	"github.com/microsoft/terratest-abstraction/retry"
 )
 
 // TerraformOutput Models terraform output key values
@@ -19,37 +23,135 @@ type TerraformOutput map[string]interface{}
 // TerraformOutputValidation A function that can validate terraform output
 type TerraformOutputValidation func(goTest *testing.T, output TerraformOutput)
 
+// EventuallyOutput Wraps an output assertion so that it is retried according to the policy until it passes. This is
+// useful for assertions that query cloud resources which are still settling after `terraform apply` completes.
+// Each attempt runs against a test harness that records its failures, so the assertion accepts a `testing.TB`
+func EventuallyOutput(policy retry.Policy, assertion func(t testing.TB, output TerraformOutput)) TerraformOutputValidation {
+	return func(t *testing.T, output TerraformOutput) {
+		retry.Eventually(t, policy, func(t testing.TB) {
+			assertion(t, output)
+		})
+	}
+}
+
 // IntegrationTestFixture Holds metadata required to execute an integration test against a test against a terraform template
 type IntegrationTestFixture struct {
 	GoTest                *testing.T                  // Go test harness
 	TfOptions             *terraform.Options          // Terraform options
 	SkipInit              bool                        // Skip running `terraform init` command when the working directory is already initialized
+	Workspace             string                      // Terraform workspace to read outputs from. The current workspace is used if empty
+	DeleteWorkspace       bool                        // Delete the workspace after the test if it differs from the starting workspace and no longer manages resources
+	Destroy               bool                        // Run `terraform destroy` after the validation and verify that no resources or workspace are left behind
+	TestDataFolder        string                      // Folder that persists data between staged runs. Defaults to the terraform directory
+	StateFile             string                      // Read outputs and state from this local state file instead of running terraform
+	RecordFile            string                      // Record the outputs and state of the run to this file so that they can be replayed
+	ReplayFile            string                      // Replay outputs and state recorded to this file instead of running terraform
 	ExpectedTfOutputCount int                         // Expected # of resources that Terraform should create
 	ExpectedTfOutput      TerraformOutput             // Expected Terraform Output
 	TfOutputAssertions    []TerraformOutputValidation // user-defined plan assertions
+	StateAssertions       []TerraformStateValidation  // user-defined state assertions
+	WarnOnUndeclaredVars  bool                        // Log supplied variables that the template does not declare instead of failing
 }
 
 // RunIntegrationTests Executes terraform lifecycle events and verifies the correctness of the resulting resources.
 // The following actions are coordinated:
-//	- Optionally run `terraform init`
-//	- Run `terraform output`
-//	- Validate outputs
-//	- Run user-supplied validation of outputs
+//   - Verify that every variable in `Vars` and `VarFiles` is declared by the template
+//   - Optionally run `terraform init`
+//   - Optionally select the terraform workspace, which must exist, restoring the starting workspace afterwards
+//   - Run `terraform output`
+//   - Validate outputs
+//   - Run user-supplied validation of outputs
+//   - Run user-supplied validation of the state, if any, which is read with `terraform show -json`
+//   - Optionally record the outputs and state to `RecordFile` before they are validated
+//   - Optionally run `terraform destroy`, verify that the state is empty and delete the workspace
+//
+// If `ReplayFile` or `StateFile` is set, the outputs and state are read from that file instead and terraform is
+// not run at all.
 func RunIntegrationTests(fixture *IntegrationTestFixture) {
+	if fixture.ReplayFile != "" {
+		validateRecording(fixture, LoadRecording(fixture.GoTest, fixture.ReplayFile))
+		return
+	}
+
+	if fixture.StateFile != "" {
+		state := ReadStateFile(fixture.GoTest, fixture.StateFile)
+		validateRecording(fixture, Recording{Output: OutputFromState(state), State: state})
+		return
+	}
+
+	variables.Verify(fixture.GoTest, fixture.TfOptions, fixture.WarnOnUndeclaredVars)
 	if !fixture.SkipInit {
 		terraform.Init(fixture.GoTest, fixture.TfOptions)
 	}
-	output := terraform.OutputAll(fixture.GoTest, fixture.TfOptions)
-	validateTerraformOutput(fixture, TerraformOutput(output))
+
+	startingWorkspaceName := ""
+	if fixture.Workspace != "" {
+		startingWorkspaceName = terraform.RunTerraformCommand(
+			fixture.GoTest,
+			fixture.TfOptions,
+			terraform.FormatArgs(fixture.TfOptions, "workspace", "show")...)
+
+		selectWorkspace(fixture.GoTest, fixture.TfOptions, fixture.Workspace)
+	}
+	if fixture.Destroy {
+		// also restores the starting workspace and deletes the workspace of the deployment
+		defer destroyAndVerify(fixture.GoTest, fixture.TfOptions, fixture.Workspace, startingWorkspaceName)
+	} else if fixture.DeleteWorkspace && fixture.Workspace != "" && startingWorkspaceName != fixture.Workspace {
+		defer func() {
+			restoreAndDeleteWorkspace(fixture.GoTest, fixture.TfOptions, fixture.Workspace, startingWorkspaceName,
+				leakedResources(fixture.GoTest, fixture.TfOptions))
+		}()
+	} else if fixture.Workspace != "" {
+		defer terraform.WorkspaceSelectOrNew(fixture.GoTest, fixture.TfOptions, startingWorkspaceName)
+	}
+
+	validateRecording(fixture, readRecording(fixture, fixture.TfOptions))
+}
+
+// Selects an existing workspace. Unlike `terraform.WorkspaceSelectOrNew`, a misspelled or missing workspace fails the
+// test instead of silently creating an empty workspace whose outputs are then validated
+func selectWorkspace(goTest *testing.T, options *terraform.Options, workspace string) {
+	workspaces := workspaceNames(terraform.RunTerraformCommand(goTest, options, "workspace", "list"))
+	for _, name := range workspaces {
+		if name == workspace {
+			terraform.RunTerraformCommand(goTest, options, "workspace", "select", workspace)
+			return
+		}
+	}
+	goTest.Fatalf("Workspace '%s' unexpectedly does not exist.%s", workspace, suggest.DidYouMean(workspace, workspaces, 1))
+}
+
+// Reads the outputs of the current workspace, and its state if the fixture validates or records it
+func readRecording(fixture *IntegrationTestFixture, options *terraform.Options) Recording {
+	recording := Recording{Output: TerraformOutput(terraform.OutputAll(fixture.GoTest, options))}
+	if len(fixture.StateAssertions) > 0 || fixture.RecordFile != "" {
+		recording.State = showState(fixture.GoTest, options)
+	}
+	return recording
+}
+
+// Records the outputs and state if requested, and then validates them
+func validateRecording(fixture *IntegrationTestFixture, recording Recording) {
+	if fixture.RecordFile != "" {
+		SaveRecording(fixture.GoTest, fixture.RecordFile, recording)
+	}
+
+	validateTerraformOutput(fixture, recording.Output)
+	if len(fixture.StateAssertions) > 0 {
+		if recording.State == nil {
+			fixture.GoTest.Fatal("State assertions cannot run because the recording does not contain any state")
+		}
+		validateTerraformState(fixture, *recording.State)
+	}
 }
 
 // Coordinates the following validations of a terraform output:
-//	- The output contains the correct number of items
-//	- The output values match any user-supplied key-value mappings. This only validates
-//	  that any user-supplied key-value mappings are correct, and will not fail if the
-//	  output has more mappings
-//	- The output has the correct number of items
-//	- Run any user-supplied assertions over the output
+//   - The output contains the correct number of items
+//   - The output values match any user-supplied key-value mappings. This only validates
+//     that any user-supplied key-value mappings are correct, and will not fail if the
+//     output has more mappings
+//   - The output has the correct number of items
+//   - Run any user-supplied assertions over the output
 func validateTerraformOutput(fixture *IntegrationTestFixture, output TerraformOutput) {
 	fixture.GoTest.Run("Terraform Output Count", func(t *testing.T) {
 		validateTerraformOutputCount(t, fixture, output)
@@ -67,6 +169,15 @@ func validateTerraformOutput(fixture *IntegrationTestFixture, output TerraformOu
 	}
 }
 
+// Runs any user-supplied assertions over the terraform state
+func validateTerraformState(fixture *IntegrationTestFixture, state tfjson.State) {
+	for i, stateAssertion := range fixture.StateAssertions {
+		fixture.GoTest.Run(fmt.Sprintf("Custom State Validation Function (%d)", i), func(t *testing.T) {
+			stateAssertion(t, state)
+		})
+	}
+}
+
 // Validates that the terraform output contains the expected number of items
 func validateTerraformOutputCount(t *testing.T, fixture *IntegrationTestFixture, output TerraformOutput) {
 	if len(output) != fixture.ExpectedTfOutputCount {
@@ -81,9 +192,9 @@ func validateTerraformOutputCount(t *testing.T, fixture *IntegrationTestFixture,
 // Validates that any outputs that the user supplies match the actual terraform outputs.
 // Note: the comparison is done by converting the expected and actual values into JSON and
 // doing a string comparison. This solves a number of complexities, such as:
-//	- Handles comparison of generic data types automatically
-//	- Handles differences in key ordering for maps
-//	- Handles all handling of generics, which is tricky in Go
+//   - Handles comparison of generic data types automatically
+//   - Handles differences in key ordering for maps
+//   - Handles all handling of generics, which is tricky in Go
 func validateTerraformOutputKeyValues(t *testing.T, fixture *IntegrationTestFixture, output TerraformOutput) {
 	for expectedKey, expectedValue := range fixture.ExpectedTfOutput {
 		actualValue, isFound := output[expectedKey]
@@ -0,0 +1,121 @@
+/*
+Package integration This file provides utilities that verify a deployment was fully torn down, so that resources
+left behind by a teardown that failed part-way are reported instead of going unnoticed.
+*/
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+// VerifyNoLeakedResources Verifies that the state of the current workspace does not contain any resources. This is
+// intended to be run after `terraform destroy` and fails the test with the address and provider of every leftover resource.
+func VerifyNoLeakedResources(goTest *testing.T, options *terraform.Options) {
+	reportLeaks(goTest, leakedResources(goTest, options))
+}
+
+// VerifyWorkspaceDeleted Verifies that the terraform workspace no longer exists
+func VerifyWorkspaceDeleted(goTest *testing.T, options *terraform.Options, workspace string) {
+	workspaces := terraform.RunTerraformCommand(goTest, options, "workspace", "list")
+	if hasWorkspace(workspaces, workspace) {
+		goTest.Errorf("Workspace '%s' unexpectedly still exists after destroy", workspace)
+	}
+}
+
+// tears down a deployment and verifies that nothing was left behind. The following actions are coordinated:
+//   - Select the workspace of the deployment, if any
+//   - Run `terraform destroy`
+//   - Verify that the state is empty
+//   - Restore the starting workspace, delete the workspace of the deployment and verify that it no longer exists
+//
+// Failures are reported without stopping the test so that the remaining teardown still runs.
+func destroyAndVerify(goTest *testing.T, options *terraform.Options, workspace string, startingWorkspace string) {
+	if workspace != "" {
+		terraform.WorkspaceSelectOrNew(goTest, options, workspace)
+	}
+
+	if _, err := terraform.DestroyE(goTest, options); err != nil {
+		goTest.Errorf("Destroy unexpectedly failed: %s", err)
+	}
+
+	leaks := leakedResources(goTest, options)
+	reportLeaks(goTest, leaks)
+
+	if workspace == "" || workspace == startingWorkspace {
+		return
+	}
+	restoreAndDeleteWorkspace(goTest, options, workspace, startingWorkspace, leaks)
+}
+
+// restores the starting workspace and deletes the workspace, unless it still manages the leftover resources. Terraform
+// refuses to delete such a workspace, so the leftover resources are reported instead
+func restoreAndDeleteWorkspace(
+	goTest *testing.T,
+	options *terraform.Options,
+	workspace string,
+	startingWorkspace string,
+	leaks []*tfjson.StateResource,
+) {
+	terraform.WorkspaceSelectOrNew(goTest, options, startingWorkspace)
+	if len(leaks) > 0 {
+		goTest.Errorf("Workspace '%s' was not deleted because it still manages %d resource(s):%s",
+			workspace, len(leaks), formatLeaks(leaks))
+		return
+	}
+	if _, err := terraform.RunTerraformCommandE(goTest, options, "workspace", "delete", workspace); err != nil {
+		goTest.Errorf("Workspace '%s' could not be deleted: %s", workspace, err)
+	}
+	VerifyWorkspaceDeleted(goTest, options, workspace)
+}
+
+// returns every resource in the state of the current workspace
+func leakedResources(goTest *testing.T, options *terraform.Options) []*tfjson.StateResource {
+	state := showState(goTest, options)
+	if state.Values == nil {
+		return nil
+	}
+	return tfstate.ModuleResources(state.Values.RootModule)
+}
+
+// fails the test with the address and provider of every leftover resource
+func reportLeaks(goTest *testing.T, leaks []*tfjson.StateResource) {
+	if len(leaks) > 0 {
+		goTest.Errorf("State unexpectedly contained %d resource(s) after destroy:%s", len(leaks), formatLeaks(leaks))
+	}
+}
+
+// formats leftover resources as a list of addresses and providers
+func formatLeaks(resources []*tfjson.StateResource) string {
+	var sb strings.Builder
+	for _, resource := range resources {
+		sb.WriteString(fmt.Sprintf("\n\t- %s (provider: %s)", resource.Address, resource.ProviderName))
+	}
+	return sb.String()
+}
+
+// returns true if the output of `terraform workspace list` contains the workspace
+func hasWorkspace(workspaceList string, workspace string) bool {
+	for _, name := range workspaceNames(workspaceList) {
+		if name == workspace {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the names of the workspaces in the output of `terraform workspace list`
+func workspaceNames(workspaceList string) []string {
+	var names []string
+	for _, line := range strings.Split(workspaceList, "\n") {
+		if name := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*")); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+const leakedStateJSON = `{
+	"format_version": "0.1",
+	"terraform_version": "1.0.0",
+	"values": {
+		"root_module": {
+			"resources": [{
+				"address": "azurerm_resource_group.rg",
+				"mode": "managed",
+				"type": "azurerm_resource_group",
+				"name": "rg",
+				"provider_name": "registry.terraform.io/hashicorp/azurerm",
+				"values": {}
+			}],
+			"child_modules": [{
+				"address": "module.network",
+				"resources": [{
+					"address": "module.network.azurerm_virtual_network.vnet",
+					"mode": "managed",
+					"type": "azurerm_virtual_network",
+					"name": "vnet",
+					"provider_name": "registry.terraform.io/hashicorp/azurerm",
+					"values": {}
+				}]
+			}]
+		}
+	}
+}`
+
+func TestModuleResourcesIncludesChildModules(t *testing.T) {
+	state := jsonToState(t, leakedStateJSON)
+
+	leaks := tfstate.ModuleResources(state.Values.RootModule)
+	if len(leaks) != 2 {
+		t.Fatalf("Expected 2 leftover resources but got %d", len(leaks))
+	}
+
+	message := formatLeaks(leaks)
+	for _, expected := range []string{
+		"azurerm_resource_group.rg (provider: registry.terraform.io/hashicorp/azurerm)",
+		"module.network.azurerm_virtual_network.vnet (provider: registry.terraform.io/hashicorp/azurerm)",
+	} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected '%s' to contain '%s'", message, expected)
+		}
+	}
+}
+
+func TestHasWorkspace(t *testing.T) {
+	workspaceList := "  default\n* integration-dev\n  integration-prod\n"
+
+	for workspace, expected := range map[string]bool{
+		"default":          true,
+		"integration-dev":  true,
+		"integration-prod": true,
+		"integration":      false,
+		"dev":              false,
+	} {
+		if hasWorkspace(workspaceList, workspace) != expected {
+			t.Errorf("Expected workspace '%s' to be found=%t", workspace, expected)
+		}
+	}
+}
+
+func TestWorkspaceNames(t *testing.T) {
+	names := workspaceNames("  default\n* integration-dev\n\n  integration-prod\n")
+	if strings.Join(names, ",") != "default,integration-dev,integration-prod" {
+		t.Fatalf("Expected the names of all workspaces but got %v", names)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Package integration This file provides abstractions that verify that several deployments of the same template, such as
+dev, staging and prod, produce terraform output of the same shape.
+*/
+package integration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// OutputSource A function that reads the terraform output of an environment
+type OutputSource func(goTest *testing.T) TerraformOutput
+
+// ParityTestFixture Holds metadata required to compare the terraform output of several environments
+type ParityTestFixture struct {
+	GoTest             *testing.T              // Go test harness
+	Environments       map[string]OutputSource // Output of each environment, keyed by environment name
+	AllowedDifferences []string                // Output keys (or nested paths such as `vnet.tags`) that are expected to differ
+}
+
+// RunParityTests Reads the terraform output of every environment and verifies that they have the same shape. The
+// environment that sorts first by name is used as the baseline, and every other environment must have:
+//   - The same output keys as the baseline
+//   - Values of the same types as the baseline, including the keys and element types of nested objects and lists
+//
+// Values themselves are not compared, and paths listed in `AllowedDifferences` are not checked at all.
+func RunParityTests(fixture *ParityTestFixture) {
+	names := make([]string, 0, len(fixture.Environments))
+	for name := range fixture.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) < 2 {
+		fixture.GoTest.Fatalf("Parity tests need at least 2 environments but %d were supplied", len(names))
+	}
+
+	outputs := make(map[string]TerraformOutput)
+	for _, name := range names {
+		outputs[name] = fixture.Environments[name](fixture.GoTest)
+	}
+
+	allowed := make(map[string]bool)
+	for _, path := range fixture.AllowedDifferences {
+		allowed[path] = true
+	}
+
+	baseline := names[0]
+	for _, name := range names[1:] {
+		fixture.GoTest.Run(fmt.Sprintf("Terraform Output Parity (%s vs %s)", name, baseline), func(t *testing.T) {
+			differences := outputDifferences(
+				map[string]interface{}(outputs[baseline]), map[string]interface{}(outputs[name]), "", allowed)
+			if len(differences) > 0 {
+				t.Fatalf("Output of environment '%s' unexpectedly differs from environment '%s':\n\t- %s",
+					name, baseline, strings.Join(differences, "\n\t- "))
+			}
+		})
+	}
+}
+
+// WorkspaceOutput Reads the output of an existing terraform workspace and restores the current workspace afterwards
+func WorkspaceOutput(options *terraform.Options, workspace string) OutputSource {
+	return func(goTest *testing.T) TerraformOutput {
+		startingWorkspaceName := terraform.RunTerraformCommand(
+			goTest,
+			options,
+			terraform.FormatArgs(options, "workspace", "show")...)
+		terraform.RunTerraformCommand(goTest, options, "workspace", "select", workspace)
+		defer terraform.RunTerraformCommand(goTest, options, "workspace", "select", startingWorkspaceName)
+
+		return TerraformOutput(terraform.OutputAll(goTest, options))
+	}
+}
+
+// StateFileOutput Reads the output recorded in a local state file without running terraform
+func StateFileOutput(stateFilePath string) OutputSource {
+	return func(goTest *testing.T) TerraformOutput {
+		return OutputFromState(ReadStateFile(goTest, stateFilePath))
+	}
+}
+
+// returns a description of every difference in keys or value types between two objects
+func outputDifferences(baseline map[string]interface{}, other map[string]interface{}, traversalPath string, allowed map[string]bool) []string {
+	keys := make(map[string]bool)
+	for key := range baseline {
+		keys[key] = true
+	}
+	for key := range other {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	var differences []string
+	for _, key := range sortedKeys {
+		currentTraversalPath := joinTraversalPath(traversalPath, key)
+		if allowed[currentTraversalPath] {
+			continue
+		}
+
+		baselineValue, inBaseline := baseline[key]
+		otherValue, inOther := other[key]
+		switch {
+		case !inOther:
+			differences = append(differences, fmt.Sprintf("'%s' is missing", currentTraversalPath))
+		case !inBaseline:
+			differences = append(differences, fmt.Sprintf("'%s' is unexpected", currentTraversalPath))
+		default:
+			differences = append(differences, valueDifferences(baselineValue, otherValue, currentTraversalPath, allowed)...)
+		}
+	}
+	return differences
+}
+
+// returns a description of every difference in shape between two values
+func valueDifferences(baseline interface{}, other interface{}, traversalPath string, allowed map[string]bool) []string {
+	if shapeOf(baseline) != shapeOf(other) {
+		return []string{fmt.Sprintf(
+			"'%s' has type '%s' instead of '%s'", traversalPath, shapeOf(other), shapeOf(baseline))}
+	}
+
+	switch typedBaseline := baseline.(type) {
+	case map[string]interface{}:
+		return outputDifferences(typedBaseline, other.(map[string]interface{}), traversalPath, allowed)
+	case []interface{}:
+		// lists may differ in length, so elements without a counterpart are compared to the first baseline element
+		var differences []string
+		for i, element := range other.([]interface{}) {
+			if len(typedBaseline) == 0 {
+				break
+			}
+			baselineElement := typedBaseline[0]
+			if i < len(typedBaseline) {
+				baselineElement = typedBaseline[i]
+			}
+			differences = append(differences, valueDifferences(
+				baselineElement, element, fmt.Sprintf("%s[%d]", traversalPath, i), allowed)...)
+		}
+		return differences
+	}
+	return nil
+}
+
+// returns the terraform name of the type of a value that was parsed from JSON
+func shapeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float32, float64, int, int64:
+		return "number"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
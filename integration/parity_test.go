@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+)
+
+var parityTests = []struct {
+	baselineJSON        string
+	otherJSON           string
+	allowed             []string
+	expectedDifferences []string
+}{
+	{
+		`{"name": "dev-vnet", "count": 1, "tags": {"env": "dev"}, "subnets": [{"name": "a"}]}`,
+		`{"name": "prod-vnet", "count": 3, "tags": {"env": "prod"}, "subnets": [{"name": "a"}, {"name": "b"}]}`,
+		nil,
+		nil,
+	}, {
+		`{"name": "dev-vnet", "count": 1}`,
+		`{"name": "prod-vnet", "debug_url": "http://localhost"}`,
+		nil,
+		[]string{"'count' is missing", "'debug_url' is unexpected"},
+	}, {
+		`{"name": "dev-vnet", "count": 1}`,
+		`{"name": "prod-vnet", "debug_url": "http://localhost"}`,
+		[]string{"count", "debug_url"},
+		nil,
+	}, {
+		`{"count": 1, "ids": ["a"], "vnet": {"tags": {"env": "dev"}}}`,
+		`{"count": "1", "ids": ["a", 2], "vnet": {"tags": {"env": "prod", "owner": "ops"}}}`,
+		nil,
+		[]string{
+			"'count' has type 'string' instead of 'number'",
+			"'ids[1]' has type 'number' instead of 'string'",
+			"'vnet.tags.owner' is unexpected",
+		},
+	}, {
+		`{"vnet": {"tags": {"env": "dev"}}}`,
+		`{"vnet": {"tags": {"env": "prod", "owner": "ops"}}}`,
+		[]string{"vnet.tags"},
+		nil,
+	}, {
+		`{"endpoint": null}`,
+		`{"endpoint": "https://example.com"}`,
+		nil,
+		[]string{"'endpoint' has type 'string' instead of 'null'"},
+	},
+}
+
+func TestOutputDifferences(t *testing.T) {
+	for _, test := range parityTests {
+		allowed := make(map[string]bool)
+		for _, path := range test.allowed {
+			allowed[path] = true
+		}
+
+		differences := outputDifferences(
+			jsonToOutput(t, test.baselineJSON), jsonToOutput(t, test.otherJSON), "", allowed)
+
+		if strings.Join(differences, "|") != strings.Join(test.expectedDifferences, "|") {
+			t.Errorf("Comparing `%s` to `%s` unexpectedly found differences %v instead of %v",
+				test.otherJSON, test.baselineJSON, differences, test.expectedDifferences)
+		}
+	}
+}
+
+func TestRunParityTests(t *testing.T) {
+	staticOutput := func(jsonStr string) OutputSource {
+		return func(goTest *testing.T) TerraformOutput {
+			return jsonToOutput(goTest, jsonStr)
+		}
+	}
+
+	RunParityTests(&ParityTestFixture{
+		GoTest: t,
+		Environments: map[string]OutputSource{
+			"dev":     staticOutput(`{"vnet_name": "dev-vnet", "subnet_ids": ["a"]}`),
+			"staging": staticOutput(`{"vnet_name": "staging-vnet", "subnet_ids": ["a", "b"]}`),
+			"prod":    staticOutput(`{"vnet_name": "prod-vnet", "subnet_ids": ["a", "b", "c"], "dr_region": "westus"}`),
+		},
+		AllowedDifferences: []string{"dr_region"},
+	})
+}
@@ -0,0 +1,205 @@
+/*
+Package integration This file provides constructors for common output validations that probe the network
+endpoints described by terraform outputs, such as "the URL in output X answers with status 200".
+*/
+package integration
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/microsoft/terratest-abstraction/retry"
+)
+
+// BodyMatcher A function that can validate the body of an HTTP response
+type BodyMatcher func(body string) error
+
+// HTTPProbe Describes an HTTP(S) request and the response that is expected for it
+type HTTPProbe struct {
+	Method         string        // HTTP method. Defaults to GET
+	Path           string        // Path that is appended to the URL read from the output
+	Headers        http.Header   // Headers added to the request
+	ExpectedStatus int           // Expected status code. Defaults to 200
+	BodyMatchers   []BodyMatcher // Matchers that the response body must satisfy
+	TLSConfig      *tls.Config   // TLS configuration, e.g. custom root CAs or `InsecureSkipVerify` for self-signed certificates
+	Timeout        time.Duration // Timeout of a single request. Defaults to 30 seconds
+}
+
+const defaultProbeTimeout = 30 * time.Second
+
+// BodyContains Matches response bodies that contain the substring
+func BodyContains(substring string) BodyMatcher {
+	return func(body string) error {
+		if !strings.Contains(body, substring) {
+			return fmt.Errorf("Response body unexpectedly did not contain '%s'", substring)
+		}
+		return nil
+	}
+}
+
+// BodyMatchesRegexp Matches response bodies that match the regular expression
+func BodyMatchesRegexp(pattern string) BodyMatcher {
+	expression := regexp.MustCompile(pattern)
+	return func(body string) error {
+		if !expression.MatchString(body) {
+			return fmt.Errorf("Response body unexpectedly did not match '%s'", pattern)
+		}
+		return nil
+	}
+}
+
+// HTTPEndpointResponds Validates that the URL held by the output responds as described by the probe. Like every probe,
+// it is retried according to the policy until the endpoint is ready, and a zero policy means `retry.DefaultPolicy`
+func HTTPEndpointResponds(outputKey string, policy retry.Policy, probe HTTPProbe) TerraformOutputValidation {
+	return func(t *testing.T, output TerraformOutput) {
+		url := stringOutputOrFail(t, output, outputKey) + probe.Path
+		// the client is shared by all attempts so that retries reuse connections instead of leaking them
+		client := probe.client()
+		defer client.CloseIdleConnections()
+		retry.Eventually(t, probePolicy(policy), func(t testing.TB) {
+			if err := probe.check(client, url); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TCPEndpointAccepts Validates that the `host:port` address held by the output accepts TCP connections within the
+// timeout, retrying according to the policy. A zero policy means `retry.DefaultPolicy`
+func TCPEndpointAccepts(outputKey string, policy retry.Policy, timeout time.Duration) TerraformOutputValidation {
+	return func(t *testing.T, output TerraformOutput) {
+		address := stringOutputOrFail(t, output, outputKey)
+		retry.Eventually(t, probePolicy(policy), func(t testing.TB) {
+			if err := dialTCP(address, timeout); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// HostnameResolves Validates that the hostname held by the output resolves, retrying according to the policy. A zero
+// policy means `retry.DefaultPolicy`. If any addresses are supplied, the hostname must resolve to each of them
+func HostnameResolves(outputKey string, policy retry.Policy, expectedAddresses ...string) TerraformOutputValidation {
+	return func(t *testing.T, output TerraformOutput) {
+		hostname := stringOutputOrFail(t, output, outputKey)
+		retry.Eventually(t, probePolicy(policy), func(t testing.TB) {
+			if err := resolveHostname(hostname, expectedAddresses); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// returns the policy, or `retry.DefaultPolicy` if it is the zero value, which would only make a single attempt
+func probePolicy(policy retry.Policy) retry.Policy {
+	if policy == (retry.Policy{}) {
+		return retry.DefaultPolicy
+	}
+	return policy
+}
+
+// returns a client that sends requests with the timeout and TLS configuration of the probe
+func (probe HTTPProbe) client() *http.Client {
+	timeout := probe.Timeout
+	if timeout == 0 {
+		timeout = defaultProbeTimeout
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: probe.TLSConfig},
+	}
+}
+
+// sends the request described by the probe with the client and returns an error describing the first mismatch, if any
+func (probe HTTPProbe) check(client *http.Client, url string) error {
+	method := probe.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	expectedStatus := probe.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return err
+	}
+	for name, values := range probe.Headers {
+		for _, value := range values {
+			request.Header.Add(name, value)
+		}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != expectedStatus {
+		return fmt.Errorf("%s %s unexpectedly responded with status %d instead of %d", method, url, response.StatusCode, expectedStatus)
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	for _, matcher := range probe.BodyMatchers {
+		if err := matcher(string(body)); err != nil {
+			return fmt.Errorf("%s %s: %s", method, url, err)
+		}
+	}
+	return nil
+}
+
+// opens and closes a TCP connection to the address
+func dialTCP(address string, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = defaultProbeTimeout
+	}
+	connection, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return fmt.Errorf("Address '%s' unexpectedly did not accept TCP connections: %s", address, err)
+	}
+	return connection.Close()
+}
+
+// resolves the hostname and verifies that every expected address is among the results
+func resolveHostname(hostname string, expectedAddresses []string) error {
+	addresses, err := net.LookupHost(hostname)
+	if err != nil {
+		return fmt.Errorf("Hostname '%s' unexpectedly did not resolve: %s", hostname, err)
+	}
+
+	resolved := make(map[string]bool)
+	for _, address := range addresses {
+		resolved[address] = true
+	}
+	for _, expected := range expectedAddresses {
+		if !resolved[expected] {
+			return fmt.Errorf("Hostname '%s' resolved to %v which unexpectedly did not include '%s'", hostname, addresses, expected)
+		}
+	}
+	return nil
+}
+
+// returns the string value of the output or fails the test if it is missing or not a string
+func stringOutputOrFail(t *testing.T, output TerraformOutput, outputKey string) string {
+	value, isFound := output[outputKey]
+	if !isFound {
+		t.Fatalf("Output unexpectedly did not contain key %s", outputKey)
+	}
+	asString, isString := value.(string)
+	if !isString {
+		t.Fatalf("Output '%s' was unexpectedly of type '%T' instead of a string", outputKey, value)
+	}
+	return asString
+}
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/microsoft/terratest-abstraction/retry"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "healthy", "version": "1.2.3"}`)
+	}))
+}
+
+func TestHTTPEndpointResponds(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	HTTPEndpointResponds("url", retry.Policy{}, HTTPProbe{
+		Path:         "/health",
+		BodyMatchers: []BodyMatcher{BodyContains("healthy"), BodyMatchesRegexp(`"version": "\d+\.\d+\.\d+"`)},
+	})(t, TerraformOutput{"url": server.URL})
+}
+
+func TestHTTPEndpointRespondsOverTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "ok")
+	}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	HTTPEndpointResponds("url", retry.Policy{}, HTTPProbe{
+		TLSConfig:    &tls.Config{RootCAs: roots},
+		BodyMatchers: []BodyMatcher{BodyContains("ok")},
+	})(t, TerraformOutput{"url": server.URL})
+
+	probe := HTTPProbe{}
+	client := probe.client()
+	defer client.CloseIdleConnections()
+	if err := probe.check(client, server.URL); err == nil {
+		t.Fatal("Expected the self-signed certificate to be rejected without a TLS configuration")
+	}
+}
+
+func TestHTTPEndpointRespondsAfterRetries(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	HTTPEndpointResponds("url", retry.Policy{MaxAttempts: 5, Interval: time.Millisecond}, HTTPProbe{})(t, TerraformOutput{"url": server.URL})
+
+	if requests != 3 {
+		t.Fatalf("Expected 3 requests but got %d", requests)
+	}
+}
+
+var httpProbeMismatchTests = []struct {
+	path  string
+	probe HTTPProbe
+}{
+	{"/missing", HTTPProbe{}},
+	{"/health", HTTPProbe{ExpectedStatus: http.StatusCreated}},
+	{"/health", HTTPProbe{BodyMatchers: []BodyMatcher{BodyContains("unhealthy")}}},
+	{"/health", HTTPProbe{BodyMatchers: []BodyMatcher{BodyMatchesRegexp(`^\[`)}}},
+}
+
+func TestHTTPProbeReportsMismatches(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	for _, test := range httpProbeMismatchTests {
+		client := test.probe.client()
+		err := test.probe.check(client, server.URL+test.path)
+		client.CloseIdleConnections()
+		if err == nil {
+			t.Errorf("Probe %+v of path '%s' was unexpectedly satisfied", test.probe, test.path)
+		}
+	}
+}
+
+func TestTCPEndpointAccepts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	TCPEndpointAccepts("address", retry.Policy{}, time.Second)(t, TerraformOutput{"address": address})
+
+	listener.Close()
+	if err := dialTCP(address, time.Second); err == nil {
+		t.Fatalf("Expected the closed address '%s' to refuse connections", address)
+	}
+}
+
+func TestHostnameResolves(t *testing.T) {
+	HostnameResolves("hostname", retry.Policy{}, "127.0.0.1")(t, TerraformOutput{"hostname": "localhost"})
+
+	if err := resolveHostname("localhost", []string{"192.0.2.1"}); err == nil {
+		t.Fatal("Expected 'localhost' not to resolve to '192.0.2.1'")
+	}
+}
+
+func TestProbePolicyDefaultsToRetries(t *testing.T) {
+	if policy := probePolicy(retry.Policy{}); policy != retry.DefaultPolicy {
+		t.Fatalf("Expected the default policy for a zero policy but got %+v", policy)
+	}
+	custom := retry.Policy{MaxAttempts: 2}
+	if policy := probePolicy(custom); policy != custom {
+		t.Fatalf("Expected the custom policy to be kept but got %+v", policy)
+	}
+}
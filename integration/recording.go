@@ -0,0 +1,31 @@
+/*
+Package integration This file provides utilities that record the outputs and state of a real integration run to a
+file and replay them later. Replaying allows user-defined assertions to be tested and debugged offline without
+deploying anything.
+*/
+package integration
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// Recording Models the outputs and state recorded from an integration run.
+// Note: recordings contain the same values as the state, including sensitive ones, so they should be stored accordingly.
+type Recording struct {
+	Output TerraformOutput `json:"output"`
+	State  *tfjson.State   `json:"state,omitempty"`
+}
+
+// SaveRecording Saves a recording to a file, creating its folder if needed
+func SaveRecording(goTest *testing.T, path string, recording Recording) {
+	saveTestData(goTest, path, recording)
+}
+
+// LoadRecording Loads a recording from a file
+func LoadRecording(goTest *testing.T, path string) Recording {
+	var recording Recording
+	loadTestData(goTest, path, &recording)
+	return recording
+}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terratest-abstraction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	recordingPath := filepath.Join(dir, "recordings", "azure.json")
+
+	var recordedResources, replayedResources int
+	countResources := func(count *int) TerraformStateValidation {
+		return func(t *testing.T, state tfjson.State) {
+			*count = len(tfstate.ModuleResources(state.Values.RootModule))
+		}
+	}
+
+	RunIntegrationTests(&IntegrationTestFixture{
+		GoTest:                t,
+		StateFile:             "testdata/terraform.tfstate",
+		RecordFile:            recordingPath,
+		ExpectedTfOutputCount: 2,
+		StateAssertions:       []TerraformStateValidation{countResources(&recordedResources)},
+	})
+
+	RunIntegrationTests(&IntegrationTestFixture{
+		GoTest:                t,
+		ReplayFile:            recordingPath,
+		ExpectedTfOutputCount: 2,
+		ExpectedTfOutput:      TerraformOutput{"resource_group_name": "MyTestResourceGroup"},
+		StateAssertions:       []TerraformStateValidation{countResources(&replayedResources)},
+	})
+
+	if recordedResources != 5 || replayedResources != recordedResources {
+		t.Fatalf("Expected 5 resources to be recorded and replayed but got %d and %d", recordedResources, replayedResources)
+	}
+}
+
+func TestReplayWithoutState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terratest-abstraction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	recordingPath := filepath.Join(dir, "output-only.json")
+
+	SaveRecording(t, recordingPath, Recording{Output: TerraformOutput{"vnet_name": "vnet"}})
+	recording := LoadRecording(t, recordingPath)
+
+	if recording.State != nil || recording.Output["vnet_name"] != "vnet" {
+		t.Fatalf("Recording was not replayed correctly: %+v", recording)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Package integration This file provides abstractions for integration-testing deployments that are split across
+several terraform root modules, where downstream stacks consume the outputs of upstream stacks as variables.
+*/
+package integration
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// StackOutput Identifies an output of a stack that is deployed earlier in the same multi-stack test
+type StackOutput struct {
+	Stack  string // Name of the upstream stack
+	Output string // Name of the output of the upstream stack
+}
+
+// Stack Describes a terraform root module that is deployed as part of a multi-stack integration test
+type Stack struct {
+	Name                  string                      // Unique name of the stack, used to reference its outputs and label its tests
+	TfOptions             *terraform.Options          // Terraform options
+	Workspace             string                      // Terraform workspace to deploy into, deleted after destroy. The current workspace is used if empty
+	Inputs                map[string]StackOutput      // Variables of this stack that are set from the outputs of upstream stacks
+	ExpectedTfOutputCount int                         // Expected # of outputs that the stack should produce
+	ExpectedTfOutput      TerraformOutput             // Expected Terraform Output
+	TfOutputAssertions    []TerraformOutputValidation // user-defined output assertions
+	StateAssertions       []TerraformStateValidation  // user-defined state assertions
+	RecordFile            string                      // Record the outputs and state of the stack to this file so that they can be replayed
+}
+
+// MultiStackTestFixture Holds metadata required to execute an integration test against several dependent terraform templates
+type MultiStackTestFixture struct {
+	GoTest *testing.T // Go test harness
+	Stacks []Stack    // Stacks in the order in which they should be deployed
+}
+
+// RunMultiStackIntegrationTests Deploys each stack in order, verifies the correctness of its outputs and tears every
+// stack down in reverse order once the test completes. The following actions are coordinated for each stack:
+//   - Set the variables of the stack from the outputs of the upstream stacks
+//   - Run `terraform init` and optionally select (or create) the terraform workspace
+//   - Run `terraform apply`
+//   - Run `terraform output`
+//   - Validate outputs and state just like `RunIntegrationTests` does
+//   - Run `terraform destroy` after all downstream stacks have been destroyed
+//   - Verify that the state is empty and that the workspace, if any, was deleted
+//
+// The options of each stack are copied before the variables are set, so the supplied options are not modified.
+func RunMultiStackIntegrationTests(fixture *MultiStackTestFixture) {
+	outputs := make(map[string]TerraformOutput)
+
+	for _, stack := range fixture.Stacks {
+		if _, isDuplicate := outputs[stack.Name]; isDuplicate {
+			fixture.GoTest.Fatalf("Stack name '%s' is used more than once", stack.Name)
+		}
+
+		options, err := stack.TfOptions.Clone()
+		if err != nil {
+			fixture.GoTest.Fatal(err)
+		}
+		if options.Vars, err = stackVars(stack, outputs); err != nil {
+			fixture.GoTest.Fatal(err)
+		}
+
+		terraform.Init(fixture.GoTest, options)
+		startingWorkspaceName := ""
+		if stack.Workspace != "" {
+			startingWorkspaceName = terraform.RunTerraformCommand(
+				fixture.GoTest,
+				options,
+				terraform.FormatArgs(options, "workspace", "show")...)
+			terraform.WorkspaceSelectOrNew(fixture.GoTest, options, stack.Workspace)
+		}
+
+		// deferred calls run in reverse order, which tears down downstream stacks before the stacks they depend on
+		defer destroyAndVerify(fixture.GoTest, options, stack.Workspace, startingWorkspaceName)
+		terraform.Apply(fixture.GoTest, options)
+
+		stackFixture := &IntegrationTestFixture{
+			GoTest:                fixture.GoTest,
+			TfOptions:             options,
+			RecordFile:            stack.RecordFile,
+			ExpectedTfOutputCount: stack.ExpectedTfOutputCount,
+			ExpectedTfOutput:      stack.ExpectedTfOutput,
+			TfOutputAssertions:    stack.TfOutputAssertions,
+			StateAssertions:       stack.StateAssertions,
+		}
+		recording := readRecording(stackFixture, options)
+		outputs[stack.Name] = recording.Output
+
+		fixture.GoTest.Run(fmt.Sprintf("Stack %s", stack.Name), func(t *testing.T) {
+			stackFixture.GoTest = t
+			validateRecording(stackFixture, recording)
+		})
+	}
+}
+
+// builds the variables of a stack by merging its configured variables with the wired outputs of the upstream stacks
+func stackVars(stack Stack, upstreamOutputs map[string]TerraformOutput) (map[string]interface{}, error) {
+	vars := make(map[string]interface{})
+	for name, value := range stack.TfOptions.Vars {
+		vars[name] = value
+	}
+
+	for variable, source := range stack.Inputs {
+		output, isFound := upstreamOutputs[source.Stack]
+		if !isFound {
+			return nil, fmt.Errorf(
+				"Stack '%s' depends on stack '%s' which is not deployed before it", stack.Name, source.Stack)
+		}
+		value, isFound := output[source.Output]
+		if !isFound {
+			return nil, fmt.Errorf(
+				"Stack '%s' depends on output '%s' which stack '%s' does not have", stack.Name, source.Output, source.Stack)
+		}
+		vars[variable] = value
+	}
+
+	return vars, nil
+}
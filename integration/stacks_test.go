@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+var upstreamOutputs = map[string]TerraformOutput{
+	"network": {"subnet_id": "subnet-1", "vnet_name": "vnet"},
+}
+
+var stackVarsTests = []struct {
+	inputs     map[string]StackOutput
+	expected   map[string]interface{}
+	shouldPass bool
+}{
+	{
+		nil,
+		map[string]interface{}{"location": "centralus"},
+		true,
+	}, {
+		map[string]StackOutput{"subnet": {Stack: "network", Output: "subnet_id"}},
+		map[string]interface{}{"location": "centralus", "subnet": "subnet-1"},
+		true,
+	}, {
+		map[string]StackOutput{"location": {Stack: "network", Output: "vnet_name"}},
+		map[string]interface{}{"location": "vnet"},
+		true, // wired outputs take precedence over configured variables
+	}, {
+		map[string]StackOutput{"subnet": {Stack: "data", Output: "subnet_id"}},
+		nil,
+		false, // upstream stack is not deployed
+	}, {
+		map[string]StackOutput{"subnet": {Stack: "network", Output: "subnet_name"}},
+		nil,
+		false, // upstream stack does not have the output
+	},
+}
+
+func TestStackVars(t *testing.T) {
+	for _, test := range stackVarsTests {
+		stack := Stack{
+			Name: "app",
+			TfOptions: &terraform.Options{
+				Vars: map[string]interface{}{"location": "centralus"},
+			},
+			Inputs: test.inputs,
+		}
+
+		vars, err := stackVars(stack, upstreamOutputs)
+		if stack.TfOptions.Vars["location"] != "centralus" {
+			t.Fatal("The configured variables of the stack were unexpectedly modified")
+		}
+		if test.shouldPass && err != nil {
+			t.Errorf("Inputs %v unexpectedly failed to resolve. %s", test.inputs, err)
+			continue
+		}
+		if !test.shouldPass {
+			if err == nil {
+				t.Errorf("Inputs %v were unexpectedly resolved", test.inputs)
+			}
+			continue
+		}
+
+		if len(vars) != len(test.expected) {
+			t.Errorf("Expected variables %v but got %v", test.expected, vars)
+		}
+		for name, value := range test.expected {
+			if vars[name] != value {
+				t.Errorf("Expected variable '%s' to be '%v' but got '%v'", name, value, vars[name])
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Package integration This file provides a staged integration test lifecycle. Each stage can be skipped by setting an
+environment variable, which allows a developer to deploy once and then iterate on the validation stage locally.
+*/
+package integration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Names of the stages of `RunIntegrationTestStages`. A stage is skipped when the environment variable
+// `SKIP_<stage>` is set (e.g., `SKIP_destroy=true`), which matches the convention of terratest's `test_structure`.
+const (
+	StageInit     = "init"
+	StageApply    = "apply"
+	StageValidate = "validate"
+	StageDestroy  = "destroy"
+)
+
+const skipStageEnvVarPrefix = "SKIP_"
+
+// files in which state is persisted between staged runs. The options file matches the one used by terratest's
+// `test_structure` so that it can be shared with existing terratest code
+const (
+	testDataDirName           = ".test-data"
+	tfOptionsFileName         = "TerraformOptions.json"
+	recordingFileName         = "TerraformRecording.json"
+	startingWorkspaceFileName = "StartingWorkspace.json"
+)
+
+// RunIntegrationTestStages Executes the full terraform lifecycle in named stages and verifies the correctness of the
+// resulting resources. The following stages are coordinated:
+//   - init: Persist the terraform options and optionally run `terraform init`
+//   - apply: Optionally select (or create) the terraform workspace, run `terraform apply` and persist the outputs, as
+//     well as the state if the fixture validates or records it
+//   - validate: Validate the persisted outputs and state just like `RunIntegrationTests` does
+//   - destroy: Run `terraform destroy`, verify that nothing was left behind, delete the workspace and the persisted data
+//
+// Stages that are skipped load the options and outputs persisted by a previous run from `TestDataFolder`. For example,
+// a developer can deploy once with `SKIP_destroy=true` and then iterate with
+// `SKIP_init=true SKIP_apply=true SKIP_destroy=true`. `StateFile` and `ReplayFile` replace the deployment, so they
+// cannot be used with stages.
+func RunIntegrationTestStages(fixture *IntegrationTestFixture) {
+	if fixture.StateFile != "" || fixture.ReplayFile != "" {
+		fixture.GoTest.Fatal("Stages cannot run because `StateFile` or `ReplayFile` is set, which replaces the " +
+			"deployment. Use `RunIntegrationTests` instead")
+	}
+
+	testDataDir := filepath.Join(testDataFolder(fixture), testDataDirName)
+	optionsPath := filepath.Join(testDataDir, tfOptionsFileName)
+	recordingPath := filepath.Join(testDataDir, recordingFileName)
+	startingWorkspacePath := filepath.Join(testDataDir, startingWorkspaceFileName)
+
+	runStage(fixture.GoTest, StageInit, func() {
+		saveTestData(fixture.GoTest, optionsPath, fixture.TfOptions)
+		if !fixture.SkipInit {
+			terraform.Init(fixture.GoTest, fixture.TfOptions)
+		}
+	})
+
+	runStage(fixture.GoTest, StageApply, func() {
+		options := loadTerraformOptions(fixture.GoTest, optionsPath)
+		if fixture.Workspace != "" {
+			startingWorkspaceName := terraform.RunTerraformCommand(
+				fixture.GoTest,
+				options,
+				terraform.FormatArgs(options, "workspace", "show")...)
+			saveTestData(fixture.GoTest, startingWorkspacePath, startingWorkspaceName)
+			terraform.WorkspaceSelectOrNew(fixture.GoTest, options, fixture.Workspace)
+		}
+		terraform.Apply(fixture.GoTest, options)
+		saveTestData(fixture.GoTest, recordingPath, readRecording(fixture, options))
+	})
+
+	runStage(fixture.GoTest, StageValidate, func() {
+		validateRecording(fixture, LoadRecording(fixture.GoTest, recordingPath))
+	})
+
+	runStage(fixture.GoTest, StageDestroy, func() {
+		options := loadTerraformOptions(fixture.GoTest, optionsPath)
+		startingWorkspaceName := ""
+		if fixture.Workspace != "" {
+			loadTestData(fixture.GoTest, startingWorkspacePath, &startingWorkspaceName)
+		}
+		destroyAndVerify(fixture.GoTest, options, fixture.Workspace, startingWorkspaceName)
+		if err := os.RemoveAll(testDataDir); err != nil {
+			fixture.GoTest.Error(err)
+		}
+	})
+}
+
+// runs the stage unless the environment variable that skips it is set
+func runStage(goTest *testing.T, stageName string, stage func()) {
+	envVarName := skipStageEnvVarPrefix + stageName
+	if os.Getenv(envVarName) != "" {
+		goTest.Logf("The '%s' environment variable is set, so skipping stage '%s'", envVarName, stageName)
+		return
+	}
+	goTest.Logf("Running stage '%s'", stageName)
+	stage()
+}
+
+// returns the folder in which data is persisted between staged runs
+func testDataFolder(fixture *IntegrationTestFixture) string {
+	if fixture.TestDataFolder != "" {
+		return fixture.TestDataFolder
+	}
+	return fixture.TfOptions.TerraformDir
+}
+
+// loads terraform options persisted by the init stage of a previous run
+func loadTerraformOptions(goTest *testing.T, path string) *terraform.Options {
+	var options terraform.Options
+	loadTestData(goTest, path, &options)
+	return &options
+}
+
+// persists a value as JSON or fails the test if an error was encountered
+func saveTestData(goTest *testing.T, path string, value interface{}) {
+	asJSON, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		goTest.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, asJSON, 0644); err != nil {
+		goTest.Fatal(err)
+	}
+}
+
+// loads a value persisted by a previous run or fails the test if it was never persisted
+func loadTestData(goTest *testing.T, path string, value interface{}) {
+	asJSON, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		goTest.Fatalf("Test data '%s' was not found. Was the stage that persists it skipped in every run?", path)
+	}
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	if err := json.Unmarshal(asJSON, value); err != nil {
+		goTest.Fatalf("Test data '%s' could not be parsed: %s", path, err)
+	}
+}
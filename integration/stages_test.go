@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+)
+
+func TestRunStageHonorsSkipEnvVar(t *testing.T) {
+	const stageName = "unit-testing-stage"
+	envVarName := skipStageEnvVarPrefix + stageName
+	defer os.Unsetenv(envVarName)
+
+	ran := false
+	runStage(t, stageName, func() { ran = true })
+	if !ran {
+		t.Fatal("Stage was unexpectedly skipped")
+	}
+
+	os.Setenv(envVarName, "true")
+	ran = false
+	runStage(t, stageName, func() { ran = true })
+	if ran {
+		t.Fatalf("Stage unexpectedly ran while '%s' was set", envVarName)
+	}
+}
+
+func TestTestDataRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terratest-abstraction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	optionsPath := filepath.Join(dir, testDataDirName, tfOptionsFileName)
+	saveTestData(t, optionsPath, &terraform.Options{
+		TerraformDir: "../",
+		Vars:         map[string]interface{}{"prefix": "abc123"},
+	})
+	options := loadTerraformOptions(t, optionsPath)
+	if options.TerraformDir != "../" || options.Vars["prefix"] != "abc123" {
+		t.Fatalf("Options were not persisted correctly: %+v", options)
+	}
+
+	recordingPath := filepath.Join(dir, testDataDirName, recordingFileName)
+	saveTestData(t, recordingPath, Recording{Output: TerraformOutput{"vnet_name": "vnet", "subnet_count": 2}})
+	recording := LoadRecording(t, recordingPath)
+	if recording.Output["vnet_name"] != "vnet" || recording.Output["subnet_count"] != float64(2) {
+		t.Fatalf("Outputs were not persisted correctly: %v", recording.Output)
+	}
+}
+
+func TestValidateStageRunsStateAssertions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "terratest-abstraction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	state := ReadStateFile(t, "testdata/terraform.tfstate")
+	saveTestData(t, filepath.Join(dir, testDataDirName, recordingFileName),
+		Recording{Output: OutputFromState(state), State: state})
+	for _, stage := range []string{StageInit, StageApply, StageDestroy} {
+		t.Setenv(skipStageEnvVarPrefix+stage, "true")
+	}
+
+	isValidated := false
+	RunIntegrationTestStages(&IntegrationTestFixture{
+		GoTest:                t,
+		TfOptions:             &terraform.Options{TerraformDir: dir},
+		ExpectedTfOutputCount: 2,
+		StateAssertions: []TerraformStateValidation{
+			func(t *testing.T, state tfjson.State) {
+				isValidated = true
+			},
+		},
+	})
+	if !isValidated {
+		t.Fatal("Expected the state assertions to run in the validate stage")
+	}
+}
+
+func TestTestDataFolderDefaultsToTerraformDir(t *testing.T) {
+	fixture := &IntegrationTestFixture{TfOptions: &terraform.Options{TerraformDir: "../../"}}
+	if folder := testDataFolder(fixture); folder != "../../" {
+		t.Fatalf("Expected the terraform directory but got '%s'", folder)
+	}
+
+	fixture.TestDataFolder = "/tmp/test-data"
+	if folder := testDataFolder(fixture); folder != "/tmp/test-data" {
+		t.Fatalf("Expected the configured folder but got '%s'", folder)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Package integration This file provides utilities that read outputs and resources directly from a terraform state
+file. This allows validations to run without a terraform binary, `terraform init` or access to the backend, such as
+in a lightweight CI job that only downloads the state as an artifact.
+*/
+package integration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+// TerraformStateValidation A function that can validate terraform state
+type TerraformStateValidation func(goTest *testing.T, state tfjson.State)
+
+// ReadStateFile Reads a local state file without running terraform. Both the raw state file format written by
+// terraform (version 4, e.g. `terraform.tfstate`) and the JSON produced by `terraform show -json` are supported.
+func ReadStateFile(goTest *testing.T, path string) *tfjson.State {
+	stateJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	state, err := tfstate.Parse(stateJSON)
+	if err != nil {
+		goTest.Fatalf("State file '%s' could not be read: %s", path, err)
+	}
+	return state
+}
+
+// OutputFromState Returns the outputs recorded in the state
+func OutputFromState(state *tfjson.State) TerraformOutput {
+	output := make(TerraformOutput)
+	if state.Values != nil {
+		for key, value := range state.Values.Outputs {
+			if value != nil {
+				output[key] = value.Value
+			}
+		}
+	}
+	return output
+}
+
+// reads the state of the current workspace using `terraform show -json`
+func showState(goTest *testing.T, options *terraform.Options) *tfjson.State {
+	// the options may point to a plan file, which should not be confused with the state
+	stateOptions, err := options.Clone()
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	stateOptions.PlanFilePath = ""
+
+	var state tfjson.State
+	if err := json.Unmarshal([]byte(terraform.Show(goTest, stateOptions)), &state); err != nil {
+		goTest.Fatal(err)
+	}
+	return &state
+}
@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+func TestReadRawStateFile(t *testing.T) {
+	state := ReadStateFile(t, "testdata/terraform.tfstate")
+
+	output := OutputFromState(state)
+	if len(output) != 2 || output["resource_group_name"] != "MyTestResourceGroup" {
+		t.Fatalf("Outputs were not read correctly: %v", output)
+	}
+
+	resources := make(map[string]*tfjson.StateResource)
+	for _, resource := range tfstate.ModuleResources(state.Values.RootModule) {
+		resources[resource.Address] = resource
+	}
+
+	for _, address := range []string{
+		"azurerm_resource_group.rg",
+		"data.azurerm_client_config.current",
+		`module.network.module.subnets.azurerm_subnet.this["app"]`,
+		`module.network.module.subnets.azurerm_subnet.this["data"]`,
+		"module.network.azurerm_network_security_group.nsg[0]",
+	} {
+		resource, isFound := resources[address]
+		if !isFound {
+			t.Errorf("Unexpectedly could not find resource '%s' in %v", address, resources)
+			continue
+		}
+		if resource.ProviderName != "registry.terraform.io/hashicorp/azurerm" {
+			t.Errorf("Resource '%s' unexpectedly had provider '%s'", address, resource.ProviderName)
+		}
+	}
+	if len(resources) != 5 {
+		t.Errorf("Expected 5 resources but got %d", len(resources))
+	}
+
+	subnet := resources[`module.network.module.subnets.azurerm_subnet.this["data"]`]
+	if subnet == nil || !subnet.Tainted || subnet.AttributeValues["name"] != "data" {
+		t.Errorf("Resource instance was not read correctly: %+v", subnet)
+	}
+}
+
+func TestReadRawStateFileNestsModules(t *testing.T) {
+	state := ReadStateFile(t, "testdata/terraform.tfstate")
+
+	root := state.Values.RootModule
+	if len(root.ChildModules) != 1 || root.ChildModules[0].Address != "module.network" {
+		t.Fatalf("Expected a single child module 'module.network' but got %+v", root.ChildModules)
+	}
+	network := root.ChildModules[0]
+	if len(network.ChildModules) != 1 || network.ChildModules[0].Address != "module.network.module.subnets" {
+		t.Fatalf("Expected a single child module 'module.network.module.subnets' but got %+v", network.ChildModules)
+	}
+}
+
+func TestReadShowJSONStateFile(t *testing.T) {
+	state := ReadStateFile(t, "testdata/show.json")
+
+	if output := OutputFromState(state); output["resource_group_name"] != "MyTestResourceGroup" {
+		t.Fatalf("Outputs were not read correctly: %v", output)
+	}
+	if resources := tfstate.ModuleResources(state.Values.RootModule); len(resources) != 1 {
+		t.Fatalf("Expected 1 resource but got %d", len(resources))
+	}
+}
+
+func TestRunIntegrationTestsFromStateFile(t *testing.T) {
+	stateAssertionRan := false
+
+	RunIntegrationTests(&IntegrationTestFixture{
+		GoTest:                t,
+		StateFile:             "testdata/terraform.tfstate",
+		ExpectedTfOutputCount: 2,
+		ExpectedTfOutput: TerraformOutput{
+			"subnet_ids": []string{"subnet-app", "subnet-data"},
+		},
+		StateAssertions: []TerraformStateValidation{
+			func(t *testing.T, state tfjson.State) {
+				stateAssertionRan = true
+			},
+		},
+	})
+
+	if !stateAssertionRan {
+		t.Fatal("State assertion was unexpectedly not run")
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Package integration This file provides a module upgrade test. It deploys a module at one source, such as the last
+released git tag, then plans the deployment against another source, such as the working copy, and verifies that the
+upgrade only performs the actions that are expected for each resource. Any replacement is reported along with the
+reason terraform gives for it.
+*/
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/unit"
+)
+
+// ModuleSource Identifies a version of a terraform configuration
+type ModuleSource struct {
+	Dir    string // Directory of the terraform configuration, relative to the working directory of the test
+	GitRef string // Optional git branch, tag or commit. If set, `Dir` is read from a checkout of the ref instead of the working copy
+}
+
+// UpgradeTestFixture Holds metadata required to verify that a deployment can be upgraded from one source to another
+type UpgradeTestFixture struct {
+	GoTest         *testing.T                     // Go test harness
+	TfOptions      *terraform.Options             // Terraform options shared by both sources. `TerraformDir` is replaced by the directory of each source
+	From           ModuleSource                   // Source that is deployed first
+	To             ModuleSource                   // Source that the deployment is upgraded to
+	Workspace      string                         // Workspace of the deployment. Defaults to a unique name so that the copied state is isolated
+	AllowedActions map[string][]tfjson.Actions    // Actions allowed per resource address during the upgrade. Resources that are not listed must be unchanged
+	PlanAssertions []unit.TerraformPlanValidation // user-defined assertions over the upgrade plan
+}
+
+// RunUpgradeTests Deploys the module at the `From` source and verifies the plan to upgrade it to the `To` source. The
+// following actions are coordinated:
+//   - Check out the git ref of each source, if any, into a temporary worktree
+//   - Run `terraform init` for both sources and select (or create) the workspace of the deployment
+//   - Run `terraform apply` for the `From` source
+//   - Copy the state to the `To` source using `terraform state pull` and `terraform state push`
+//   - Run `terraform plan` for the `To` source and validate that every planned action is allowed
+//   - Run `terraform destroy` for the `From` source, verify that nothing was left behind and delete the copied state
+//
+// Every replacement in the upgrade plan is logged along with its reason and the attributes that forced it.
+func RunUpgradeTests(fixture *UpgradeTestFixture) {
+	goTest := fixture.GoTest
+
+	fromOptions := sourceOptions(goTest, fixture.TfOptions, fixture.From)
+	toOptions := sourceOptions(goTest, fixture.TfOptions, fixture.To)
+
+	workspace := fixture.Workspace
+	if workspace == "" {
+		workspace = "upgrade-" + strings.ToLower(random.UniqueId())
+	}
+
+	terraform.Init(goTest, fromOptions)
+	fromStartingWorkspace := currentWorkspace(goTest, fromOptions)
+	terraform.WorkspaceSelectOrNew(goTest, fromOptions, workspace)
+
+	terraform.Init(goTest, toOptions)
+	toStartingWorkspace := currentWorkspace(goTest, toOptions)
+
+	defer func() {
+		// the copied state must only be deleted once the deployment is destroyed, because both sources may share a backend
+		destroyAndVerify(goTest, fromOptions, workspace, fromStartingWorkspace)
+		deleteStateCopy(goTest, toOptions, workspace, toStartingWorkspace)
+	}()
+
+	terraform.Apply(goTest, fromOptions)
+	copyState(goTest, fromOptions, toOptions, workspace)
+
+	plan := planUpgrade(goTest, toOptions)
+
+	for _, replacement := range replacements(*plan) {
+		goTest.Logf("Upgrade replaces %s", replacement)
+	}
+
+	goTest.Run("Terraform Upgrade Actions Are Allowed", func(t *testing.T) {
+		if disallowed := disallowedUpgradeActions(*plan, fixture.AllowedActions); len(disallowed) > 0 {
+			t.Fatalf("Upgrade unexpectedly plans actions that are not allowed:\n\t- %s", strings.Join(disallowed, "\n\t- "))
+		}
+	})
+
+	for i, planAssertion := range fixture.PlanAssertions {
+		goTest.Run(fmt.Sprintf("Custom Validation Function (%d)", i), func(t *testing.T) {
+			planAssertion(t, *plan)
+		})
+	}
+}
+
+// returns a copy of the options that points to the directory of the source. If the source has a git ref, the ref is
+// checked out into a temporary worktree that is removed once the test completes
+func sourceOptions(goTest *testing.T, options *terraform.Options, source ModuleSource) *terraform.Options {
+	clone, err := options.Clone()
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	clone.TerraformDir = source.Dir
+	if source.GitRef == "" {
+		return clone
+	}
+
+	absoluteDir, err := filepath.Abs(source.Dir)
+	if err != nil {
+		goTest.Fatal(err)
+	}
+	if absoluteDir, err = filepath.EvalSymlinks(absoluteDir); err != nil {
+		goTest.Fatal(err)
+	}
+	repositoryRoot := strings.TrimSpace(shell.RunCommandAndGetStdOut(goTest, shell.Command{
+		Command:    "git",
+		Args:       []string{"rev-parse", "--show-toplevel"},
+		WorkingDir: absoluteDir,
+	}))
+	relativeDir, err := filepath.Rel(repositoryRoot, absoluteDir)
+	if err != nil {
+		goTest.Fatal(err)
+	}
+
+	worktree := filepath.Join(os.TempDir(), "upgrade-"+random.UniqueId())
+	shell.RunCommand(goTest, shell.Command{
+		Command:    "git",
+		Args:       []string{"worktree", "add", "--detach", worktree, source.GitRef},
+		WorkingDir: repositoryRoot,
+	})
+	goTest.Cleanup(func() {
+		shell.RunCommand(goTest, shell.Command{
+			Command:    "git",
+			Args:       []string{"worktree", "remove", "--force", worktree},
+			WorkingDir: repositoryRoot,
+		})
+	})
+
+	clone.TerraformDir = filepath.Join(worktree, relativeDir)
+	return clone
+}
+
+// returns the name of the selected workspace
+func currentWorkspace(goTest *testing.T, options *terraform.Options) string {
+	return terraform.RunTerraformCommand(goTest, options, terraform.FormatArgs(options, "workspace", "show")...)
+}
+
+// copies the state of the workspace from one configuration to another
+func copyState(goTest *testing.T, fromOptions *terraform.Options, toOptions *terraform.Options, workspace string) {
+	state, err := terraform.RunTerraformCommandAndGetStdoutE(goTest, fromOptions, "state", "pull")
+	if err != nil {
+		goTest.Fatal(err)
+	}
+
+	statePath := filepath.FromSlash(fmt.Sprintf("%s/%s.tfstate", os.TempDir(), random.UniqueId()))
+	defer os.Remove(statePath)
+	if err := ioutil.WriteFile(statePath, []byte(state), 0600); err != nil {
+		goTest.Fatal(err)
+	}
+
+	terraform.WorkspaceSelectOrNew(goTest, toOptions, workspace)
+	terraform.RunTerraformCommand(goTest, toOptions, "state", "push", statePath)
+}
+
+// restores the starting workspace and deletes the copied state, unless it was already deleted along with the deployment
+func deleteStateCopy(goTest *testing.T, options *terraform.Options, workspace string, startingWorkspace string) {
+	if workspace == startingWorkspace {
+		return
+	}
+	terraform.WorkspaceSelectOrNew(goTest, options, startingWorkspace)
+	if !hasWorkspace(terraform.RunTerraformCommand(goTest, options, "workspace", "list"), workspace) {
+		return
+	}
+	// the copy still references the destroyed resources, so it has to be deleted forcefully
+	if _, err := terraform.RunTerraformCommandE(goTest, options, "workspace", "delete", "-force", workspace); err != nil {
+		goTest.Errorf("Workspace '%s' could not be deleted: %s", workspace, err)
+	}
+}
+
+// runs `terraform plan` in the current workspace and returns the parsed plan
+func planUpgrade(goTest *testing.T, options *terraform.Options) *tfjson.Plan {
+	tfPlanFilePath := filepath.FromSlash(fmt.Sprintf("%s/%s.plan", os.TempDir(), random.UniqueId()))
+	defer os.Remove(tfPlanFilePath)
+
+	terraform.RunTerraformCommand(
+		goTest,
+		options,
+		terraform.FormatArgs(options, "plan", "-input=false", "-out", tfPlanFilePath)...)
+	planJSON, err := terraform.RunTerraformCommandAndGetStdoutE(goTest, options, "show", "-json", tfPlanFilePath)
+	if err != nil {
+		goTest.Fatal(err)
+	}
+
+	var plan tfjson.Plan
+	if err := json.Unmarshal([]byte(planJSON), &plan); err != nil {
+		goTest.Fatal(err)
+	}
+	return &plan
+}
+
+// returns a description of every change to a managed resource that is neither a no-op nor allowed for its address
+func disallowedUpgradeActions(plan tfjson.Plan, allowed map[string][]tfjson.Actions) []string {
+	var disallowed []string
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil || change.Mode != tfjson.ManagedResourceMode || change.Change.Actions.NoOp() {
+			continue
+		}
+		if isActionAllowed(change.Change.Actions, allowed[change.Address]) {
+			continue
+		}
+
+		description := fmt.Sprintf("'%s' will be %s", change.Address, describeActions(change.Change.Actions))
+		if change.Change.Actions.Replace() {
+			description += replacementReason(change)
+		}
+		disallowed = append(disallowed, description)
+	}
+	sort.Strings(disallowed)
+	return disallowed
+}
+
+// returns true if the actions are among the allowed actions. Replacements match regardless of whether the resource
+// is created before or after it is destroyed
+func isActionAllowed(actions tfjson.Actions, allowed []tfjson.Actions) bool {
+	for _, allowedActions := range allowed {
+		if reflect.DeepEqual(actions, allowedActions) || (actions.Replace() && allowedActions.Replace()) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns a description of every replacement in the plan, including its reason
+func replacements(plan tfjson.Plan) []string {
+	var replaced []string
+	for _, change := range plan.ResourceChanges {
+		if change != nil && change.Change != nil && change.Change.Actions.Replace() {
+			replaced = append(replaced, fmt.Sprintf("'%s'%s", change.Address, replacementReason(change)))
+		}
+	}
+	sort.Strings(replaced)
+	return replaced
+}
+
+// describes planned actions in the passive voice, e.g. "replaced"
+func describeActions(actions tfjson.Actions) string {
+	switch {
+	case actions.Replace():
+		return "replaced"
+	case actions.Create():
+		return "created"
+	case actions.Update():
+		return "updated in-place"
+	case actions.Delete():
+		return "destroyed"
+	case actions.Read():
+		return "read"
+	}
+	return fmt.Sprintf("changed by %v", actions)
+}
+
+// describes why terraform replaces a resource, e.g. ` (reason: replace_because_cannot_update, forced by: location)`
+func replacementReason(change *tfjson.ResourceChange) string {
+	var details []string
+	if change.ActionReason != tfjson.ActionReasonNone {
+		details = append(details, "reason: "+string(change.ActionReason))
+	}
+
+	var paths []string
+	for _, path := range change.Change.ReplacePaths {
+		if steps, ok := path.([]interface{}); ok {
+			paths = append(paths, formatAttributePath(steps))
+		}
+	}
+	if len(paths) > 0 {
+		details = append(details, "forced by: "+strings.Join(paths, ", "))
+	}
+
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// formats the steps of an attribute path as reported by terraform, e.g. `subnet[0].name`
+func formatAttributePath(steps []interface{}) string {
+	traversalPath := ""
+	for _, step := range steps {
+		switch typedStep := step.(type) {
+		case float64:
+			traversalPath = fmt.Sprintf("%s[%d]", traversalPath, int(typedStep))
+		default:
+			traversalPath = joinTraversalPath(traversalPath, fmt.Sprint(typedStep))
+		}
+	}
+	return traversalPath
+}
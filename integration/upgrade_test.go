@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+const upgradePlanJSON = `{
+	"format_version": "1.2",
+	"resource_changes": [{
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["no-op"]}
+	}, {
+		"address": "azurerm_virtual_network.vnet",
+		"mode": "managed",
+		"change": {"actions": ["update"]}
+	}, {
+		"address": "azurerm_subnet.subnet[0]",
+		"mode": "managed",
+		"action_reason": "replace_because_cannot_update",
+		"change": {"actions": ["delete", "create"], "replace_paths": [["address_prefixes", 0], ["name"]]}
+	}, {
+		"address": "azurerm_public_ip.ip",
+		"mode": "managed",
+		"action_reason": "replace_by_triggers",
+		"change": {"actions": ["create", "delete"]}
+	}, {
+		"address": "data.azurerm_client_config.current",
+		"mode": "data",
+		"change": {"actions": ["read"]}
+	}]
+}`
+
+func TestDisallowedUpgradeActions(t *testing.T) {
+	plan := jsonToPlan(t, upgradePlanJSON)
+
+	disallowed := disallowedUpgradeActions(plan, nil)
+	expected := []string{
+		"'azurerm_public_ip.ip' will be replaced (reason: replace_by_triggers)",
+		"'azurerm_subnet.subnet[0]' will be replaced (reason: replace_because_cannot_update, forced by: address_prefixes[0], name)",
+		"'azurerm_virtual_network.vnet' will be updated in-place",
+	}
+	if strings.Join(disallowed, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %v but got %v", expected, disallowed)
+	}
+}
+
+func TestAllowedUpgradeActions(t *testing.T) {
+	plan := jsonToPlan(t, upgradePlanJSON)
+
+	allowed := map[string][]tfjson.Actions{
+		"azurerm_virtual_network.vnet": {{tfjson.ActionUpdate}},
+		// replacements match regardless of the order of create and delete
+		"azurerm_subnet.subnet[0]": {{tfjson.ActionCreate, tfjson.ActionDelete}},
+		"azurerm_public_ip.ip":     {{tfjson.ActionUpdate}, {tfjson.ActionDelete, tfjson.ActionCreate}},
+	}
+	if disallowed := disallowedUpgradeActions(plan, allowed); len(disallowed) > 0 {
+		t.Fatalf("Expected all actions to be allowed but got %v", disallowed)
+	}
+
+	allowed["azurerm_subnet.subnet[0]"] = []tfjson.Actions{{tfjson.ActionUpdate}}
+	if disallowed := disallowedUpgradeActions(plan, allowed); len(disallowed) != 1 {
+		t.Fatalf("Expected the replacement of the subnet to be disallowed but got %v", disallowed)
+	}
+}
+
+func TestReplacements(t *testing.T) {
+	plan := jsonToPlan(t, upgradePlanJSON)
+
+	replaced := replacements(plan)
+	if len(replaced) != 2 || !strings.HasPrefix(replaced[0], "'azurerm_public_ip.ip' (reason: replace_by_triggers)") {
+		t.Fatalf("Expected 2 replacements but got %v", replaced)
+	}
+}
+
+func TestFormatAttributePath(t *testing.T) {
+	testCases := []struct {
+		steps    []interface{}
+		expected string
+	}{
+		{[]interface{}{"location"}, "location"},
+		{[]interface{}{"subnet", float64(1), "name"}, "subnet[1].name"},
+		{[]interface{}{"tags", "env"}, "tags.env"},
+	}
+	for _, testCase := range testCases {
+		if actual := formatAttributePath(testCase.steps); actual != testCase.expected {
+			t.Errorf("Expected '%s' but got '%s'", testCase.expected, actual)
+		}
+	}
+}
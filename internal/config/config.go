@@ -0,0 +1,122 @@
+/*
+Package config This file provides utilities that read a terraform configuration directly from its files, before
+`terraform init` has run or without a terraform binary at all.
+*/
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// the registry that terraform assumes for provider sources without a hostname, e.g. `hashicorp/azurerm`
+const defaultRegistry = "registry.terraform.io"
+
+// schema of the blocks that declare the providers required by a configuration file
+var (
+	terraformSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+	}
+	requiredProvidersSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+	}
+)
+
+// Files Parses the configuration files (`.tf` and `.tf.json`) in the directory
+func Files(dir string) ([]*hcl.File, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := hclparse.NewParser()
+	var files []*hcl.File
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		var parsed *hcl.File
+		var diagnostics hcl.Diagnostics
+		switch {
+		case entry.IsDir():
+			continue
+		case strings.HasSuffix(entry.Name(), ".tf"):
+			parsed, diagnostics = parser.ParseHCLFile(path)
+		case strings.HasSuffix(entry.Name(), ".tf.json"):
+			parsed, diagnostics = parser.ParseJSONFile(path)
+		default:
+			continue
+		}
+		if diagnostics.HasErrors() {
+			return nil, diagnostics
+		}
+		files = append(files, parsed)
+	}
+	return files, nil
+}
+
+// ProviderSources Returns the fully qualified source of every provider that the configuration in the directory
+// declares in `required_providers` with a `source`, keyed by its local name, e.g.
+// `registry.terraform.io/azure/azapi` for `azapi`
+func ProviderSources(dir string) (map[string]string, error) {
+	files, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+	for _, file := range files {
+		content, _, diagnostics := file.Body.PartialContent(terraformSchema)
+		if diagnostics.HasErrors() {
+			return nil, diagnostics
+		}
+		for _, terraformBlock := range content.Blocks {
+			nestedContent, _, diagnostics := terraformBlock.Body.PartialContent(requiredProvidersSchema)
+			if diagnostics.HasErrors() {
+				return nil, diagnostics
+			}
+			for _, requiredProviders := range nestedContent.Blocks {
+				if err := readProviderSources(requiredProviders.Body, sources); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return sources, nil
+}
+
+// reads the source of every provider of a `required_providers` block. Providers that are only constrained by a
+// version, e.g. `azurerm = "~> 2.0"`, do not declare a source
+func readProviderSources(body hcl.Body, sources map[string]string) error {
+	attributes, diagnostics := body.JustAttributes()
+	if diagnostics.HasErrors() {
+		return diagnostics
+	}
+	for name, attribute := range attributes {
+		value, diagnostics := attribute.Expr.Value(nil)
+		if diagnostics.HasErrors() {
+			return diagnostics
+		}
+		if !value.Type().IsObjectType() || !value.Type().HasAttribute("source") {
+			continue
+		}
+		source := value.GetAttr("source")
+		if source.IsNull() || !source.IsKnown() || source.Type() != cty.String {
+			return fmt.Errorf("Source of required provider '%s' is not a string", name)
+		}
+		sources[name] = qualifiedSource(source.AsString())
+	}
+	return nil
+}
+
+// returns the source with the hostname of the registry, e.g. `registry.terraform.io/azure/azapi` for `azure/azapi`
+func qualifiedSource(source string) string {
+	if strings.Count(source, "/") == 1 {
+		return defaultRegistry + "/" + source
+	}
+	return source
+}
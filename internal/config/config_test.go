@@ -0,0 +1,31 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	files, err := Files("testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected the .tf and .tf.json files to be parsed but got %d file(s)", len(files))
+	}
+}
+
+func TestProviderSources(t *testing.T) {
+	sources, err := ProviderSources("testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"azapi":   "registry.terraform.io/azure/azapi",
+		"private": "example.com/contoso/private",
+	}
+	if !reflect.DeepEqual(sources, expected) {
+		t.Fatalf("Expected provider sources %v but got %v", expected, sources)
+	}
+}
@@ -0,0 +1,14 @@
+terraform {
+  required_providers {
+    azurerm = "~> 2.0"
+    azapi = {
+      source  = "azure/azapi"
+      version = "~> 1.0"
+    }
+  }
+}
+
+resource "azapi_resource" "example" {
+  type = "Microsoft.Resources/resourceGroups@2021-04-01"
+  name = "example"
+}
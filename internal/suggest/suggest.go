@@ -0,0 +1,130 @@
+/*
+Package suggest This file provides "did you mean" suggestions for names that could not be found, such as misspelled
+variable names, resource addresses or attribute names. Candidates are ranked by their edit distance to the name.
+*/
+package suggest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// the fewest and the most edits that are considered a likely typo, regardless of the length of the name. Long names,
+// such as resource addresses, share most of their characters with unrelated candidates, so the distance is capped
+const (
+	minimumMaxDistance = 2
+	maximumMaxDistance = 3
+)
+
+// Distance Returns the Levenshtein distance between two strings, i.e. the number of single character insertions,
+// deletions and substitutions that turn one into the other
+func Distance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			substitution := previous[j-1]
+			if source[i-1] != target[j-1] {
+				substitution++
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+// Ranked Returns at most `limit` candidates that are likely meant instead of the name, closest first. A candidate is
+// likely meant if it is at most a third of the length of the name (but at least 2 and at most 3) edits away. Resource
+// addresses are also compared without their module path, so that `azurerm_subnet.this` suggests
+// `module.network.azurerm_subnet.this`
+func Ranked(name string, candidates []string, limit int) []string {
+	distances := make(map[string]int)
+	var ranked []string
+	for _, candidate := range candidates {
+		if _, isDuplicate := distances[candidate]; isDuplicate || candidate == name {
+			continue
+		}
+		if distance, isLikely := likelyDistance(name, candidate); isLikely {
+			distances[candidate] = distance
+			ranked = append(ranked, candidate)
+		}
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if distances[ranked[i]] != distances[ranked[j]] {
+			return distances[ranked[i]] < distances[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// returns the distance between the name and the candidate and whether the candidate is likely meant. If their module
+// paths differ, the distance of the rest of the addresses is used, so a missing or wrong module path is not a typo
+func likelyDistance(name string, candidate string) (int, bool) {
+	distance := Distance(name, candidate)
+	if distance <= maxDistance(name) {
+		return distance, true
+	}
+
+	resource, candidateResource := withoutModulePath(name), withoutModulePath(candidate)
+	if resource == name && candidateResource == candidate {
+		return distance, false
+	}
+	resourceDistance := Distance(resource, candidateResource)
+	return resourceDistance, resourceDistance <= maxDistance(resource)
+}
+
+// returns the most edits that are considered a likely typo of the name
+func maxDistance(name string) int {
+	return min(max(len([]rune(name))/3, minimumMaxDistance), maximumMaxDistance)
+}
+
+// returns the address without its module path, e.g. `azurerm_subnet.this` for `module.network.azurerm_subnet.this`
+func withoutModulePath(address string) string {
+	for strings.HasPrefix(address, "module.") {
+		separator := strings.Index(address[len("module."):], ".")
+		if separator < 0 {
+			return address
+		}
+		address = address[len("module.")+separator+1:]
+	}
+	return address
+}
+
+// Closest Returns the candidate that is most likely meant instead of the name, or an empty string if there is none
+func Closest(name string, candidates []string) string {
+	if ranked := Ranked(name, candidates, 1); len(ranked) > 0 {
+		return ranked[0]
+	}
+	return ""
+}
+
+// DidYouMean Formats the candidates that are likely meant instead of the name as a sentence that can be appended to
+// an error message, e.g. ` Did you mean 'location' or 'locations'?`. An empty string is returned if there are none
+func DidYouMean(name string, candidates []string, limit int) string {
+	ranked := Ranked(name, candidates, limit)
+	if len(ranked) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(ranked))
+	for i, candidate := range ranked {
+		quoted[i] = fmt.Sprintf("'%s'", candidate)
+	}
+	if len(quoted) == 1 {
+		return fmt.Sprintf(" Did you mean %s?", quoted[0])
+	}
+	return fmt.Sprintf(" Did you mean %s or %s?", strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
+}
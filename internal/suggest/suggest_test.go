@@ -0,0 +1,106 @@
+package suggest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"", "", 0},
+		{"location", "location", 0},
+		{"locaton", "location", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"vnet", "vent", 2},
+	}
+	for _, test := range tests {
+		if actual := Distance(test.a, test.b); actual != test.expected {
+			t.Errorf("Expected distance %d between '%s' and '%s' but got %d", test.expected, test.a, test.b, actual)
+		}
+	}
+}
+
+func TestRanked(t *testing.T) {
+	candidates := []string{"location", "locations", "name", "resource_group_location", "location"}
+
+	ranked := Ranked("locaton", candidates, 0)
+	if strings.Join(ranked, ",") != "location,locations" {
+		t.Fatalf("Expected 'location' and 'locations' but got %v", ranked)
+	}
+	if ranked := Ranked("locaton", candidates, 1); len(ranked) != 1 {
+		t.Fatalf("Expected the limit to be applied but got %v", ranked)
+	}
+	if ranked := Ranked("tags", candidates, 0); len(ranked) != 0 {
+		t.Fatalf("Expected no candidates for an unrelated name but got %v", ranked)
+	}
+}
+
+func TestRankedCapsEditsForLongNames(t *testing.T) {
+	candidates := []string{"module.network.azurerm_virtual_network.vnet", "module.network.azurerm_subnet.subnet"}
+
+	ranked := Ranked("module.networks.azurerm_virtual_netwrk.vnet", candidates, 0)
+	if strings.Join(ranked, ",") != candidates[0] {
+		t.Fatalf("Expected only '%s' but got %v", candidates[0], ranked)
+	}
+	if ranked := Ranked("module.network.azurerm_network_interface.nic", candidates, 0); len(ranked) != 0 {
+		t.Fatalf("Expected no candidates for an unrelated address but got %v", ranked)
+	}
+}
+
+func TestRankedIgnoresModulePaths(t *testing.T) {
+	candidates := []string{
+		"module.network.azurerm_virtual_network.vnet",
+		"module.network.azurerm_subnet.subnet",
+		"azurerm_resource_group.rg",
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"azurerm_virtual_network.vnet", "module.network.azurerm_virtual_network.vnet"},
+		{"module.vnet.azurerm_virtual_netwrk.vnet", "module.network.azurerm_virtual_network.vnet"},
+		{`module.rg["dev"].azurerm_resource_group.rg`, "azurerm_resource_group.rg"},
+		{"module.network.azurerm_network_interface.nic", ""},
+	}
+	for _, test := range tests {
+		if ranked := Ranked(test.name, candidates, 0); strings.Join(ranked, ",") != test.expected {
+			t.Errorf("Expected '%s' for '%s' but got %v", test.expected, test.name, ranked)
+		}
+	}
+}
+
+func TestWithoutModulePath(t *testing.T) {
+	for address, expected := range map[string]string{
+		"azurerm_subnet.this":                           "azurerm_subnet.this",
+		"module.network.azurerm_subnet.this":            "azurerm_subnet.this",
+		`module.network.module.subnets["app"].data.x.y`: "data.x.y",
+		"module":         "module",
+		"module.network": "module.network",
+	} {
+		if actual := withoutModulePath(address); actual != expected {
+			t.Errorf("Expected '%s' for '%s' but got '%s'", expected, address, actual)
+		}
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"locaton", " Did you mean 'location' or 'locations'?"},
+		{"nme", " Did you mean 'name'?"},
+		{"tags", ""},
+	}
+	for _, test := range tests {
+		if actual := DidYouMean(test.name, []string{"location", "locations", "name"}, 3); actual != test.expected {
+			t.Errorf("Expected '%s' but got '%s'", test.expected, actual)
+		}
+	}
+}
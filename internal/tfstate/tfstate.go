@@ -0,0 +1,196 @@
+/*
+Package tfstate This file provides utilities for the terraform state that are shared by unit tests and integration
+tests. Unit tests write the raw state file format, e.g. `terraform.tfstate`, to seed a workspace with a prior state,
+and integration tests read it to validate a deployment without running terraform.
+*/
+package tfstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// SupportedVersion The only raw state format version that can be read and written
+const SupportedVersion = 4
+
+// Raw Models the raw state file format (version 4) written by terraform and read by `terraform state push`
+type Raw struct {
+	Version          int                  `json:"version"`
+	TerraformVersion string               `json:"terraform_version"`
+	Serial           int                  `json:"serial"`
+	Lineage          string               `json:"lineage"`
+	Outputs          map[string]RawOutput `json:"outputs"`
+	Resources        []*RawResource       `json:"resources"`
+}
+
+// RawOutput Models an output of the raw state file format
+type RawOutput struct {
+	Value     interface{} `json:"value"`
+	Sensitive bool        `json:"sensitive,omitempty"`
+}
+
+// RawResource Models a resource of the raw state file format, which holds every instance of the resource
+type RawResource struct {
+	Module    string                `json:"module,omitempty"`
+	Mode      tfjson.ResourceMode   `json:"mode"`
+	Type      string                `json:"type"`
+	Name      string                `json:"name"`
+	Provider  string                `json:"provider"`
+	Instances []RawResourceInstance `json:"instances"`
+}
+
+// RawResourceInstance Models a resource instance of the raw state file format
+type RawResourceInstance struct {
+	IndexKey      interface{}            `json:"index_key,omitempty"`
+	Status        string                 `json:"status,omitempty"`
+	Deposed       string                 `json:"deposed,omitempty"`
+	SchemaVersion uint64                 `json:"schema_version"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	Dependencies  []string               `json:"dependencies,omitempty"`
+}
+
+// Parse Parses either the raw state file format or the JSON produced by `terraform show -json` into the model used
+// by `terraform show -json`
+func Parse(stateJSON []byte) (*tfjson.State, error) {
+	var header struct {
+		Version       *int   `json:"version"`
+		FormatVersion string `json:"format_version"`
+	}
+	if err := json.Unmarshal(stateJSON, &header); err != nil {
+		return nil, err
+	}
+
+	if header.FormatVersion != "" {
+		var state tfjson.State
+		if err := json.Unmarshal(stateJSON, &state); err != nil {
+			return nil, err
+		}
+		return &state, nil
+	}
+
+	if header.Version == nil || *header.Version != SupportedVersion {
+		return nil, fmt.Errorf("Only state format version %d is supported", SupportedVersion)
+	}
+	var raw Raw
+	if err := json.Unmarshal(stateJSON, &raw); err != nil {
+		return nil, err
+	}
+	return raw.ToState(), nil
+}
+
+// ToState Converts a raw state into the model used by `terraform show -json`
+func (raw Raw) ToState() *tfjson.State {
+	state := &tfjson.State{
+		FormatVersion:    "0.1",
+		TerraformVersion: raw.TerraformVersion,
+		Values: &tfjson.StateValues{
+			Outputs:    make(map[string]*tfjson.StateOutput),
+			RootModule: &tfjson.StateModule{},
+		},
+	}
+
+	for key, output := range raw.Outputs {
+		state.Values.Outputs[key] = &tfjson.StateOutput{Sensitive: output.Sensitive, Value: output.Value}
+	}
+
+	modules := map[string]*tfjson.StateModule{"": state.Values.RootModule}
+	for _, resource := range raw.Resources {
+		if resource == nil {
+			continue
+		}
+		module := stateModule(modules, resource.Module)
+		for _, instance := range resource.Instances {
+			module.Resources = append(module.Resources, &tfjson.StateResource{
+				Address:         resourceAddress(resource, instance.IndexKey),
+				Mode:            resource.Mode,
+				Type:            resource.Type,
+				Name:            resource.Name,
+				Index:           instance.IndexKey,
+				ProviderName:    providerName(resource.Provider),
+				SchemaVersion:   instance.SchemaVersion,
+				AttributeValues: instance.Attributes,
+				DependsOn:       instance.Dependencies,
+				Tainted:         instance.Status == "tainted",
+				DeposedKey:      instance.Deposed,
+			})
+		}
+	}
+
+	return state
+}
+
+// ModuleResources Returns the resources of a state module and all of its child modules
+func ModuleResources(module *tfjson.StateModule) []*tfjson.StateResource {
+	if module == nil {
+		return nil
+	}
+
+	var resources []*tfjson.StateResource
+	for _, resource := range module.Resources {
+		if resource != nil {
+			resources = append(resources, resource)
+		}
+	}
+	for _, child := range module.ChildModules {
+		resources = append(resources, ModuleResources(child)...)
+	}
+	return resources
+}
+
+// returns the state module with the address, creating it and its parent modules if they do not exist
+func stateModule(modules map[string]*tfjson.StateModule, address string) *tfjson.StateModule {
+	if module, isFound := modules[address]; isFound {
+		return module
+	}
+
+	// module addresses look like `module.network.module.subnets["app"]`, so the parent address
+	// is everything before the last `.module.`
+	parentAddress := ""
+	if separator := strings.LastIndex(address, ".module."); separator >= 0 {
+		parentAddress = address[:separator]
+	}
+	parent := stateModule(modules, parentAddress)
+
+	module := &tfjson.StateModule{Address: address}
+	parent.ChildModules = append(parent.ChildModules, module)
+	modules[address] = module
+	return module
+}
+
+// returns the absolute address of a resource instance, e.g. `module.network.azurerm_subnet.this["app"]`
+func resourceAddress(resource *RawResource, indexKey interface{}) string {
+	address := fmt.Sprintf("%s.%s", resource.Type, resource.Name)
+	if resource.Mode == tfjson.DataResourceMode {
+		address = "data." + address
+	}
+	if resource.Module != "" {
+		address = resource.Module + "." + address
+	}
+
+	switch typedKey := indexKey.(type) {
+	case string:
+		address = fmt.Sprintf("%s[%q]", address, typedKey)
+	case float64:
+		address = fmt.Sprintf("%s[%d]", address, int(typedKey))
+	case int:
+		address = fmt.Sprintf("%s[%d]", address, typedKey)
+	}
+	return address
+}
+
+// returns the provider name from a provider configuration address, e.g. `registry.terraform.io/hashicorp/azurerm`
+// from `module.network.provider["registry.terraform.io/hashicorp/azurerm"].west`
+func providerName(providerAddress string) string {
+	start := strings.Index(providerAddress, `provider["`)
+	if start < 0 {
+		return providerAddress
+	}
+	name := providerAddress[start+len(`provider["`):]
+	if end := strings.Index(name, `"]`); end >= 0 {
+		name = name[:end]
+	}
+	return name
+}
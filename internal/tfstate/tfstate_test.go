@@ -0,0 +1,48 @@
+package tfstate
+
+import (
+	"testing"
+)
+
+func TestParseRejectsUnsupportedVersions(t *testing.T) {
+	for _, stateJSON := range []string{`{"version": 3}`, `{}`, `{"format_version": "9.0"}`} {
+		if _, err := Parse([]byte(stateJSON)); err == nil {
+			t.Errorf("State `%s` was unexpectedly parsed", stateJSON)
+		}
+	}
+}
+
+func TestRawToStateNestsModules(t *testing.T) {
+	state := Raw{
+		Version: SupportedVersion,
+		Resources: []*RawResource{{
+			Module:    `module.network.module.subnets`,
+			Mode:      "managed",
+			Type:      "azurerm_subnet",
+			Name:      "this",
+			Provider:  `module.network.provider["registry.terraform.io/hashicorp/azurerm"]`,
+			Instances: []RawResourceInstance{{IndexKey: "app"}, {IndexKey: 1}},
+		}},
+	}.ToState()
+
+	resources := ModuleResources(state.Values.RootModule)
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources but got %d", len(resources))
+	}
+	if resources[0].Address != `module.network.module.subnets.azurerm_subnet.this["app"]` ||
+		resources[1].Address != `module.network.module.subnets.azurerm_subnet.this[1]` {
+		t.Errorf("Resource addresses were not built correctly: '%s' and '%s'", resources[0].Address, resources[1].Address)
+	}
+	if resources[0].ProviderName != "registry.terraform.io/hashicorp/azurerm" {
+		t.Errorf("Expected the provider name to be read from the provider address but got '%s'", resources[0].ProviderName)
+	}
+	if len(state.Values.RootModule.ChildModules) != 1 {
+		t.Errorf("Expected a single child module but got %d", len(state.Values.RootModule.ChildModules))
+	}
+}
+
+func TestModuleResourcesOfEmptyState(t *testing.T) {
+	if resources := ModuleResources(nil); len(resources) != 0 {
+		t.Fatalf("Expected no resources but got %d", len(resources))
+	}
+}
@@ -0,0 +1,14 @@
+variable "location" {
+  type    = string
+  default = "centralus"
+}
+
+variable "resource_group_name" {
+  type = string
+}
+
+resource "null_resource" "example" {
+  triggers = {
+    location = var.location
+  }
+}
@@ -0,0 +1,135 @@
+/*
+Package variables This file provides utilities that compare the variables supplied to terraform with the variables
+declared by a terraform configuration. Terraform only warns about values for undeclared variables, so a misspelled
+variable name silently leaves the variable at its default.
+*/
+package variables
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/microsoft/terratest-abstraction/internal/config"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+)
+
+// schema of the blocks that declare variables in a configuration file
+var variableSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+}
+
+// Verify Fails the test if any variable supplied by the options is not declared by the configuration. If `warnOnly`
+// is set, the undeclared variables are logged instead
+func Verify(goTest *testing.T, options *terraform.Options, warnOnly bool) {
+	undeclared, err := Undeclared(options)
+	if err != nil {
+		goTest.Fatalf("Declared variables could not be read: %s", err)
+	}
+	if len(undeclared) == 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Template unexpectedly does not declare %d supplied variable(s):\n\t- %s",
+		len(undeclared), strings.Join(undeclared, "\n\t- "))
+	if warnOnly {
+		goTest.Log(message)
+		return
+	}
+	goTest.Fatal(message)
+}
+
+// Undeclared Returns a description of every variable in `Vars` and in the files of `VarFiles` that is not declared by
+// the configuration in `TerraformDir`, including the closest declared name if there is one
+func Undeclared(options *terraform.Options) ([]string, error) {
+	declared, err := Declared(options.TerraformDir)
+	if err != nil {
+		return nil, err
+	}
+	isDeclared := make(map[string]bool)
+	for _, name := range declared {
+		isDeclared[name] = true
+	}
+
+	var undeclared []string
+	describe := func(name string, source string) {
+		if !isDeclared[name] {
+			undeclared = append(undeclared, fmt.Sprintf(
+				"'%s' (%s) is not declared.%s", name, source, suggest.DidYouMean(name, declared, 1)))
+		}
+	}
+
+	for name := range options.Vars {
+		describe(name, "Vars")
+	}
+	for _, varFile := range options.VarFiles {
+		path := varFile
+		if !filepath.IsAbs(path) {
+			// terraform resolves var files relative to the directory it runs in
+			path = filepath.Join(options.TerraformDir, path)
+		}
+		names, err := VarFileNames(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			describe(name, "var file "+varFile)
+		}
+	}
+
+	sort.Strings(undeclared)
+	return undeclared, nil
+}
+
+// Declared Returns the names of the variables declared by the configuration files (`.tf` and `.tf.json`) in the directory
+func Declared(dir string) ([]string, error) {
+	files, err := config.Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var declared []string
+	for _, file := range files {
+		content, _, diagnostics := file.Body.PartialContent(variableSchema)
+		if diagnostics.HasErrors() {
+			return nil, diagnostics
+		}
+		for _, block := range content.Blocks {
+			declared = append(declared, block.Labels[0])
+		}
+	}
+
+	sort.Strings(declared)
+	return declared, nil
+}
+
+// VarFileNames Returns the names of the variables assigned by a variable file (`.tfvars` or `.tfvars.json`)
+func VarFileNames(path string) ([]string, error) {
+	parser := hclparse.NewParser()
+	var parsed *hcl.File
+	var diagnostics hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		parsed, diagnostics = parser.ParseJSONFile(path)
+	} else {
+		parsed, diagnostics = parser.ParseHCLFile(path)
+	}
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+
+	attributes, diagnostics := parsed.Body.JustAttributes()
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
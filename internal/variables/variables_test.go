@@ -0,0 +1,73 @@
+package variables
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+func TestDeclared(t *testing.T) {
+	declared, err := Declared("testdata/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(declared, ",") != "location,resource_group_name,tags" {
+		t.Fatalf("Expected variables declared in .tf and .tf.json files but got %v", declared)
+	}
+}
+
+func TestVarFileNames(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"testdata/dev.tfvars", "location,resorce_group_name"},
+		{"testdata/dev.tfvars.json", "tags,tgas"},
+	}
+	for _, test := range tests {
+		names, err := VarFileNames(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(names, ",") != test.expected {
+			t.Errorf("Expected variables '%s' in %s but got %v", test.expected, test.path, names)
+		}
+	}
+}
+
+func TestUndeclared(t *testing.T) {
+	absoluteVarFile, err := filepath.Abs("testdata/dev.tfvars.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := &terraform.Options{
+		TerraformDir: "testdata/config",
+		Vars:         map[string]interface{}{"location": "eastus", "locaton": "eastus", "subscription": "0000"},
+		VarFiles:     []string{"../dev.tfvars", absoluteVarFile},
+	}
+
+	undeclared, err := Undeclared(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"'locaton' (Vars) is not declared. Did you mean 'location'?",
+		"'resorce_group_name' (var file ../dev.tfvars) is not declared. Did you mean 'resource_group_name'?",
+		"'subscription' (Vars) is not declared.",
+		"'tgas' (var file " + absoluteVarFile + ") is not declared. Did you mean 'tags'?",
+	}
+	if strings.Join(undeclared, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %v but got %v", expected, undeclared)
+	}
+}
+
+func TestUndeclaredFailsForMissingFiles(t *testing.T) {
+	if _, err := Undeclared(&terraform.Options{TerraformDir: "testdata/missing"}); err == nil {
+		t.Fatal("Expected an error for a missing directory")
+	}
+	if _, err := Undeclared(&terraform.Options{TerraformDir: "testdata/config", VarFiles: []string{"missing.tfvars"}}); err == nil {
+		t.Fatal("Expected an error for a missing var file")
+	}
+}
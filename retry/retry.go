@@ -0,0 +1,186 @@
+/*
+Package retry This file provides utilities for re-running test assertions against infrastructure that is
+eventually consistent. Cloud resources often take some time to settle after `terraform apply` completes, so
+an assertion that fails on the first try may pass a few seconds later.
+*/
+package retry
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Policy Describes how often and for how long an assertion is retried before the failure is reported.
+// A policy without a timeout or a maximum number of attempts only makes a single attempt.
+type Policy struct {
+	Timeout     time.Duration // Stop retrying once this much time has passed since the first attempt. Zero means no limit
+	MaxAttempts int           // Stop retrying after this many attempts. Zero means no limit
+	Interval    time.Duration // Time to wait after the first failed attempt
+	Backoff     float64       // Factor applied to the wait after each failed attempt. Values below 1 mean a constant wait
+	MaxInterval time.Duration // Upper bound for the wait between attempts. Zero means no bound
+}
+
+// DefaultPolicy A policy that is suitable for most post-deployment assertions
+var DefaultPolicy = Policy{
+	Timeout:     5 * time.Minute,
+	Interval:    5 * time.Second,
+	Backoff:     2,
+	MaxInterval: time.Minute,
+}
+
+// Attempt Describes the outcome of a single run of an assertion
+type Attempt struct {
+	Number   int           // 1-based attempt number
+	Started  time.Time     // Time at which the attempt started
+	Duration time.Duration // Time the assertion took to complete
+	Passed   bool          // Whether the assertion passed
+	Panic    interface{}   // Value recovered from a panic in the assertion, if any
+	Messages []string      // Messages logged and failures reported by the assertion, in order
+}
+
+func (a Attempt) String() string {
+	outcome := "failed"
+	if a.Passed {
+		outcome = "passed"
+	} else if a.Panic != nil {
+		outcome = fmt.Sprintf("panicked: %v", a.Panic)
+	}
+	return fmt.Sprintf("attempt %d at %s took %s and %s", a.Number, a.Started.Format(time.RFC3339), a.Duration, outcome)
+}
+
+// Eventually Runs an assertion until it passes or the policy expires. The following actions are coordinated:
+//   - Run the assertion against a recording test harness so that failures do not fail the real test
+//   - Wait according to the policy and try again until the assertion passes
+//   - Once the policy expires, fail the real test with the messages of the last attempt and the attempt history
+//
+// The assertion is never run more often than the policy allows. Assertions that call `t.Run`, `t.Parallel`,
+// `t.Skip` or other harness-specific functions are not supported.
+func Eventually(goTest testing.TB, policy Policy, assertion func(t testing.TB)) []Attempt {
+	var history []Attempt
+	start := time.Now()
+	wait := policy.Interval
+
+	for number := 1; ; number++ {
+		attempt := try(goTest, number, assertion)
+		history = append(history, attempt)
+
+		if attempt.Passed {
+			goTest.Logf("Assertion passed after %d attempt(s)", number)
+			return history
+		}
+		if policy.isExpired(number, time.Since(start)+wait) {
+			break
+		}
+
+		time.Sleep(wait)
+		wait = policy.nextInterval(wait)
+	}
+
+	lastAttempt := history[len(history)-1]
+	attempts := make([]string, len(history))
+	for i, attempt := range history {
+		attempts[i] = attempt.String()
+	}
+	goTest.Fatalf("Assertion did not pass after %d attempt(s) in %s:\n\t%s\nLast failure:\n\t%s",
+		len(history), time.Since(start), strings.Join(attempts, "\n\t"), strings.Join(lastAttempt.Messages, "\n\t"))
+	return history
+}
+
+// runs a single attempt of the assertion against a recording test harness. The assertion is run in a separate
+// goroutine because `t.FailNow` (and therefore `t.Fatal`) stops the goroutine that calls it
+func try(goTest testing.TB, number int, assertion func(t testing.TB)) (attempt Attempt) {
+	attempt.Number = number
+	attempt.Started = time.Now()
+
+	recorder := &recorder{TB: goTest}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			attempt.Panic = recover()
+		}()
+		assertion(recorder)
+	}()
+	<-done
+
+	attempt.Duration = time.Since(attempt.Started)
+	attempt.Messages = recorder.messages
+	if attempt.Panic != nil {
+		attempt.Messages = append(attempt.Messages, fmt.Sprintf("panic: %v", attempt.Panic))
+	}
+	attempt.Passed = attempt.Panic == nil && !recorder.failed
+	return attempt
+}
+
+// recorder A test harness that records the messages and the failure of a single attempt instead of reporting them
+// to the real test harness. Functions that are not recorded, such as `Name` or `TempDir`, use the real test harness
+type recorder struct {
+	testing.TB
+	messages []string
+	failed   bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Log(args ...interface{}) { r.messages = append(r.messages, fmt.Sprint(args...)) }
+
+func (r *recorder) Logf(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fail() { r.failed = true }
+
+func (r *recorder) Failed() bool { return r.failed }
+
+// FailNow Marks the attempt as failed and stops the goroutine of the attempt, as `testing.T` does
+func (r *recorder) FailNow() {
+	r.failed = true
+	runtime.Goexit()
+}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.Log(args...)
+	r.Fail()
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.Logf(format, args...)
+	r.Fail()
+}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.Log(args...)
+	r.FailNow()
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Logf(format, args...)
+	r.FailNow()
+}
+
+// returns true if no further attempt should be made given the number of attempts so far and the
+// time that will have elapsed by the start of the next attempt
+func (p Policy) isExpired(attempts int, elapsed time.Duration) bool {
+	if p.MaxAttempts > 0 && attempts >= p.MaxAttempts {
+		return true
+	}
+	if p.Timeout > 0 {
+		return elapsed > p.Timeout
+	}
+	// a policy without any limit only makes a single attempt
+	return p.MaxAttempts == 0
+}
+
+// returns the wait before the next attempt given the wait before the current one
+func (p Policy) nextInterval(wait time.Duration) time.Duration {
+	if p.Backoff > 1 {
+		wait = time.Duration(float64(wait) * p.Backoff)
+	}
+	if p.MaxInterval > 0 && wait > p.MaxInterval {
+		wait = p.MaxInterval
+	}
+	return wait
+}
@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventuallyPassesAfterRetries(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, Interval: time.Millisecond}
+
+	calls := 0
+	history := Eventually(t, policy, func(t testing.TB) {
+		calls++
+		if calls < 3 {
+			t.Fatalf("not ready yet (%d)", calls)
+		}
+	})
+
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 attempts but got %d", len(history))
+	}
+	for i, attempt := range history {
+		if attempt.Number != i+1 {
+			t.Errorf("Expected attempt number %d but got %d", i+1, attempt.Number)
+		}
+		if attempt.Passed != (i == 2) {
+			t.Errorf("Attempt %d unexpectedly had outcome passed=%t", attempt.Number, attempt.Passed)
+		}
+	}
+}
+
+func TestEventuallyDoesNotExceedMaxAttempts(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, Interval: time.Millisecond}
+
+	calls := 0
+	assertion := func(t testing.TB) {
+		calls++
+		if calls <= 3 {
+			t.Fatalf("not ready yet (%d)", calls)
+		}
+	}
+
+	// the failure stops the goroutine that calls `Eventually`, as it would stop a test
+	goTest := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Eventually(goTest, policy, assertion)
+	}()
+	<-done
+
+	if calls != 3 {
+		t.Fatalf("Expected 3 calls but got %d", calls)
+	}
+	if !goTest.Failed() || len(goTest.messages) != 1 {
+		t.Fatalf("Expected the test to fail with a single message but got %v", goTest.messages)
+	}
+	if message := goTest.messages[0]; !strings.Contains(message, "after 3 attempt(s)") ||
+		!strings.Contains(message, "attempt 3 at") || !strings.HasSuffix(message, "Last failure:\n\tnot ready yet (3)") {
+		t.Fatalf("Expected the history and the last failure to be reported but got: %s", message)
+	}
+}
+
+func TestTryRecordsMessages(t *testing.T) {
+	attempt := try(t, 1, func(t testing.TB) {
+		t.Log("checking")
+		t.Errorf("not ready yet")
+		t.Fatal("giving up")
+	})
+
+	if attempt.Passed || strings.Join(attempt.Messages, ",") != "checking,not ready yet,giving up" {
+		t.Fatalf("Expected a failed attempt with recorded messages but got %s with %v", attempt, attempt.Messages)
+	}
+}
+
+func TestTryRecoversPanics(t *testing.T) {
+	attempt := try(t, 1, func(t testing.TB) {
+		panic("boom")
+	})
+
+	if attempt.Passed || attempt.Panic != "boom" {
+		t.Fatalf("Expected a failed attempt with a recovered panic but got %s", attempt)
+	}
+}
+
+func TestTryDetectsNonFatalFailures(t *testing.T) {
+	attempt := try(t, 1, func(t testing.TB) {
+		t.Errorf("not ready yet")
+	})
+
+	if attempt.Passed {
+		t.Fatal("Expected a failed attempt")
+	}
+}
+
+var policyTests = []struct {
+	policy   Policy
+	attempts int
+	elapsed  time.Duration
+	expired  bool
+}{
+	{Policy{}, 1, 0, true},
+	{Policy{MaxAttempts: 3}, 2, time.Hour, false},
+	{Policy{MaxAttempts: 3}, 3, 0, true},
+	{Policy{Timeout: time.Minute}, 10, time.Second, false},
+	{Policy{Timeout: time.Minute}, 1, 2 * time.Minute, true},
+	{Policy{Timeout: time.Minute, MaxAttempts: 2}, 2, time.Second, true},
+}
+
+func TestPolicyIsExpired(t *testing.T) {
+	for _, test := range policyTests {
+		if expired := test.policy.isExpired(test.attempts, test.elapsed); expired != test.expired {
+			t.Errorf("Policy %+v with %d attempts after %s was expected to be expired=%t",
+				test.policy, test.attempts, test.elapsed, test.expired)
+		}
+	}
+}
+
+func TestPolicyNextInterval(t *testing.T) {
+	policy := Policy{Backoff: 2, MaxInterval: 3 * time.Second}
+
+	wait := time.Second
+	for _, expected := range []time.Duration{2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		wait = policy.nextInterval(wait)
+		if wait != expected {
+			t.Fatalf("Expected an interval of %s but got %s", expected, wait)
+		}
+	}
+
+	if wait := (Policy{}).nextInterval(time.Second); wait != time.Second {
+		t.Fatalf("Expected a constant interval but got %s", wait)
+	}
+}
@@ -14,12 +14,13 @@ import (
 	"github.com/Azure/go-autorest/autorest/azure"
 
 	"github.com/microsoft/terratest-abstraction/integration"
+	"github.com/microsoft/terratest-abstraction/retry"
 	"github.com/stretchr/testify/require"
 )
 
 // ServicePrincipalAuthorizer - Configures an authorizer for the Azure SDK that can use the same
 // environment variables as was used for the terraform deployment
-func ServicePrincipalAuthorizer(t *testing.T) autorest.Authorizer {
+func ServicePrincipalAuthorizer(t testing.TB) autorest.Authorizer {
 	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, os.Getenv("ARM_TENANT_ID"))
 	require.Nil(t, err)
 	token, err := adal.NewServicePrincipalToken(*oauthConfig, os.Getenv("ARM_CLIENT_ID"), os.Getenv("ARM_CLIENT_SECRET"), azure.PublicCloud.ResourceManagerEndpoint)
@@ -27,7 +28,7 @@ func ServicePrincipalAuthorizer(t *testing.T) autorest.Authorizer {
 	return autorest.NewBearerAuthorizer(token)
 }
 
-func getVNETSubnets(t *testing.T, resourceGroupName, vnetName string) []string {
+func getVNETSubnets(t testing.TB, resourceGroupName, vnetName string) []string {
 	subnetsClient := network.NewSubnetsClient(os.Getenv("ARM_SUBSCRIPTION_ID"))
 	subnetsClient.Authorizer = ServicePrincipalAuthorizer(t)
 
@@ -46,16 +47,19 @@ func getVNETSubnets(t *testing.T, resourceGroupName, vnetName string) []string {
 	return subnetNames
 }
 
-// verifies that the VNET is configured with the correct number of subnets
-func testVNET(t *testing.T, output integration.TerraformOutput) {
-	vnetName, ok := output["vnet_name"].(string)
-	require.True(t, ok, "vnet_name was unexpectedly not a string value")
+// templateOutput Terraform outputs of the template that are used by the tests
+type templateOutput struct {
+	VNETName          string `output:"vnet_name"`
+	ResourceGroupName string `output:"resource_group_name"`
+}
 
-	resourceGroupName, ok := output["resource_group_name"].(string)
-	require.True(t, ok, "resource_group_name was unexpectedly not a string value")
+// verifies that the VNET is configured with the correct number of subnets
+func testVNET(t testing.TB, output integration.TerraformOutput) {
+	var decoded templateOutput
+	integration.DecodeOutput(t, output, &decoded)
 
 	// this operation will query Azure to identify all of the subnets within the named VNET
-	subnetIDs := getVNETSubnets(t, resourceGroupName, vnetName)
+	subnetIDs := getVNETSubnets(t, decoded.ResourceGroupName, decoded.VNETName)
 
 	// assert the correct number of subnets have been provisioned
 	require.Equal(t, 2, len(subnetIDs), fmt.Sprintf("Expected 2 subnets but found %v", len(subnetIDs)))
@@ -72,8 +76,11 @@ func TestTemplateIntegration(t *testing.T) {
 	//
 	// Note: typical deployments will have many more tests that validate different components of the deployment. In this
 	// example, we just have one test to run.
+	//
+	// Resources may take some time to settle after the deployment, so the VNET test is retried until it
+	// passes or the default retry policy expires.
 	outputValidations := []integration.TerraformOutputValidation{
-		testVNET,
+		integration.EventuallyOutput(retry.DefaultPolicy, testVNET),
 	}
 
 	testFixture := integration.IntegrationTestFixture{
@@ -85,3 +85,21 @@ func TestPlanOutputs(t *testing.T) {
 
 	unit.RunUnitTests(&testFixture)
 }
+
+func TestResourceWiring(t *testing.T) {
+
+	// These assertions verify that resources are placed in the resource group created by the template, without
+	// depending on the name of the resource group
+	testFixture := unit.UnitTestFixture{
+		GoTest:                t,
+		TfOptions:             tests.TfOptions,
+		ExpectedResourceCount: 3,
+		PlanAssertions: []unit.TerraformPlanValidation{
+			unit.AttributesLinked("azurerm_network_security_group.nsg", "resource_group_name", "azurerm_resource_group.rg", "name"),
+			unit.AttributesLinked("azurerm_virtual_network.vnet", "resource_group_name", "azurerm_resource_group.rg", "name"),
+			unit.AttributesLinked("azurerm_virtual_network.vnet", "location", "azurerm_resource_group.rg", "location"),
+		},
+	}
+
+	unit.RunUnitTests(&testFixture)
+}
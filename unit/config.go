@@ -0,0 +1,177 @@
+/*
+Package unit This file provides plan validations over the configuration section of a plan, which describes how
+resources and modules are wired together. This allows tests to verify wiring that is unknown at plan time, such as an
+attribute that is set from another resource that has not been created yet.
+
+Configuration addresses do not contain instance keys, e.g. `module.network.azurerm_subnet.this` rather than
+`module.network["a"].azurerm_subnet.this[0]`.
+*/
+package unit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// AttributeReferences Validates that the expression of a resource attribute references the address, such as
+// `var.location`, `local.tags`, `module.network.subnet_id` or `azurerm_resource_group.rg.name`. Attributes of nested
+// blocks are separated by dots, e.g. `network_profile.network_plugin`
+func AttributeReferences(resourceAddress string, attributePath string, reference string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' attribute '%s' references '%s'", resourceAddress, attributePath, reference)
+		resource := configResourceOrFail(t, plan, resourceAddress)
+
+		expressions := attributeExpressions(resource.Expressions, attributePath)
+		if len(expressions) == 0 {
+			t.Fatalf("Unexpectedly could not find attribute '%s' of '%s' in the configuration", attributePath, resourceAddress)
+		}
+		for _, expression := range expressions {
+			if referencesAddress(expression.References, reference) {
+				return
+			}
+		}
+		t.Fatalf("Attribute '%s' of '%s' unexpectedly did not reference '%s'", attributePath, resourceAddress, reference)
+	}
+}
+
+// ModuleCallPassesInput Validates that the module call passes the input variable. If any references are supplied, the
+// expression of the input must reference each of them
+func ModuleCallPassesInput(moduleAddress string, input string, references ...string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that module call '%s' passes input '%s'", moduleAddress, input)
+		moduleCall, isFound := configModuleCalls(configRootModule(plan), "")[moduleAddress]
+		if !isFound {
+			t.Fatalf("Unexpectedly could not find module call '%s' in the configuration", moduleAddress)
+		}
+
+		expression, isFound := moduleCall.Expressions[input]
+		if !isFound || expression == nil {
+			t.Fatalf("Module call '%s' unexpectedly did not pass input '%s'", moduleAddress, input)
+		}
+		for _, reference := range references {
+			if !referencesAddress(expression.References, reference) {
+				t.Fatalf("Input '%s' of module call '%s' unexpectedly did not reference '%s'", input, moduleAddress, reference)
+			}
+		}
+	}
+}
+
+// ResourceDependsOn Validates that the `depends_on` argument of a resource or module call contains the dependency
+func ResourceDependsOn(address string, dependency string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' depends on '%s'", address, dependency)
+		rootModule := configRootModule(plan)
+
+		var dependsOn []string
+		if resource, isFound := configResources(rootModule, "")[address]; isFound {
+			dependsOn = resource.DependsOn
+		} else if moduleCall, isFound := configModuleCalls(rootModule, "")[address]; isFound {
+			dependsOn = moduleCall.DependsOn
+		} else {
+			t.Fatalf("Unexpectedly could not find resource or module call '%s' in the configuration", address)
+		}
+
+		for _, actual := range dependsOn {
+			if actual == dependency {
+				return
+			}
+		}
+		t.Fatalf("'%s' unexpectedly did not depend on '%s', its dependencies are %v", address, dependency, dependsOn)
+	}
+}
+
+// returns the root module of the configuration, if any
+func configRootModule(plan tfjson.Plan) *tfjson.ConfigModule {
+	if plan.Config == nil {
+		return nil
+	}
+	return plan.Config.RootModule
+}
+
+// returns the configuration of the resource or fails the test if it does not exist
+func configResourceOrFail(t *testing.T, plan tfjson.Plan, address string) *tfjson.ConfigResource {
+	resource, isFound := configResources(configRootModule(plan), "")[address]
+	if !isFound {
+		t.Fatalf("Unexpectedly could not find resource '%s' in the configuration", address)
+	}
+	return resource
+}
+
+// returns the resources of a configuration module and all of its child modules, keyed by their absolute address
+func configResources(module *tfjson.ConfigModule, modulePrefix string) map[string]*tfjson.ConfigResource {
+	resources := make(map[string]*tfjson.ConfigResource)
+	if module == nil {
+		return resources
+	}
+
+	for _, resource := range module.Resources {
+		if resource != nil {
+			resources[modulePrefix+resource.Address] = resource
+		}
+	}
+	for name, moduleCall := range module.ModuleCalls {
+		if moduleCall == nil {
+			continue
+		}
+		for address, resource := range configResources(moduleCall.Module, modulePrefix+"module."+name+".") {
+			resources[address] = resource
+		}
+	}
+	return resources
+}
+
+// returns the module calls of a configuration module and all of its child modules, keyed by their absolute address
+func configModuleCalls(module *tfjson.ConfigModule, modulePrefix string) map[string]*tfjson.ModuleCall {
+	moduleCalls := make(map[string]*tfjson.ModuleCall)
+	if module == nil {
+		return moduleCalls
+	}
+
+	for name, moduleCall := range module.ModuleCalls {
+		if moduleCall == nil {
+			continue
+		}
+		address := modulePrefix + "module." + name
+		moduleCalls[address] = moduleCall
+		for childAddress, childCall := range configModuleCalls(moduleCall.Module, address+".") {
+			moduleCalls[childAddress] = childCall
+		}
+	}
+	return moduleCalls
+}
+
+// returns the expressions of an attribute. Attributes of nested blocks, such as `network_profile.network_plugin`,
+// may have an expression for every instance of the block
+func attributeExpressions(expressions map[string]*tfjson.Expression, attributePath string) []*tfjson.Expression {
+	name, remainingPath := attributePath, ""
+	if separator := strings.Index(attributePath, "."); separator >= 0 {
+		name, remainingPath = attributePath[:separator], attributePath[separator+1:]
+	}
+
+	expression, isFound := expressions[name]
+	if !isFound || expression == nil {
+		return nil
+	}
+	if remainingPath == "" {
+		return []*tfjson.Expression{expression}
+	}
+
+	var found []*tfjson.Expression
+	for _, block := range expression.NestedBlocks {
+		found = append(found, attributeExpressions(block, remainingPath)...)
+	}
+	return found
+}
+
+// returns true if one of the references is the address or an attribute, element or instance of it. For example,
+// `azurerm_resource_group.rg` is referenced by `azurerm_resource_group.rg.name`
+func referencesAddress(references []string, address string) bool {
+	for _, reference := range references {
+		if reference == address || strings.HasPrefix(reference, address+".") || strings.HasPrefix(reference, address+"[") {
+			return true
+		}
+	}
+	return false
+}
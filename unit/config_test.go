@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+const configPlanJSON = `{
+	"format_version": "1.2",
+	"configuration": {
+		"root_module": {
+			"resources": [{
+				"address": "azurerm_resource_group.rg",
+				"mode": "managed",
+				"type": "azurerm_resource_group",
+				"name": "rg",
+				"expressions": {
+					"name": {"references": ["var.name"]},
+					"location": {"references": ["var.location"]}
+				}
+			}, {
+				"address": "azurerm_kubernetes_cluster.aks",
+				"mode": "managed",
+				"type": "azurerm_kubernetes_cluster",
+				"name": "aks",
+				"expressions": {
+					"resource_group_name": {"references": ["azurerm_resource_group.rg.name", "azurerm_resource_group.rg"]},
+					"network_profile": [
+						{"network_plugin": {"constant_value": "azure"}},
+						{"network_plugin": {"references": ["var.network_plugin"]}}
+					]
+				},
+				"depends_on": ["module.network"]
+			}],
+			"module_calls": {
+				"network": {
+					"source": "./modules/network",
+					"expressions": {
+						"resource_group_name": {"references": ["azurerm_resource_group.rg.name", "azurerm_resource_group.rg"]}
+					},
+					"module": {
+						"resources": [{
+							"address": "azurerm_virtual_network.vnet",
+							"mode": "managed",
+							"type": "azurerm_virtual_network",
+							"name": "vnet",
+							"expressions": {"name": {"references": ["var.name"]}}
+						}],
+						"module_calls": {
+							"subnets": {
+								"source": "./subnets",
+								"expressions": {"vnet_id": {"references": ["azurerm_virtual_network.vnet.id", "azurerm_virtual_network.vnet"]}},
+								"depends_on": ["azurerm_virtual_network.vnet"],
+								"module": {}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestConfigResources(t *testing.T) {
+	resources := configResources(configRootModule(jsonToPlan(t, configPlanJSON)), "")
+
+	addresses := make([]string, 0, len(resources))
+	for address := range resources {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	expected := []string{"azurerm_kubernetes_cluster.aks", "azurerm_resource_group.rg", "module.network.azurerm_virtual_network.vnet"}
+	if len(addresses) != len(expected) {
+		t.Fatalf("Expected resources %v but got %v", expected, addresses)
+	}
+	for i := range expected {
+		if addresses[i] != expected[i] {
+			t.Errorf("Expected resource '%s' but got '%s'", expected[i], addresses[i])
+		}
+	}
+}
+
+func TestConfigModuleCalls(t *testing.T) {
+	moduleCalls := configModuleCalls(configRootModule(jsonToPlan(t, configPlanJSON)), "")
+
+	if len(moduleCalls) != 2 || moduleCalls["module.network"] == nil || moduleCalls["module.network.module.subnets"] == nil {
+		t.Fatalf("Expected module calls 'module.network' and 'module.network.module.subnets' but got %v", moduleCalls)
+	}
+	if dependsOn := moduleCalls["module.network.module.subnets"].DependsOn; len(dependsOn) != 1 {
+		t.Errorf("Expected 1 dependency of 'module.network.module.subnets' but got %v", dependsOn)
+	}
+}
+
+func TestConfigWithoutConfiguration(t *testing.T) {
+	if resources := configResources(configRootModule(tfjson.Plan{}), ""); len(resources) != 0 {
+		t.Fatalf("Expected no resources but got %v", resources)
+	}
+}
+
+func TestAttributeExpressions(t *testing.T) {
+	aks := configResources(configRootModule(jsonToPlan(t, configPlanJSON)), "")["azurerm_kubernetes_cluster.aks"]
+
+	tests := []struct {
+		attributePath string
+		expectedCount int
+	}{
+		{"resource_group_name", 1},
+		{"network_profile.network_plugin", 2},
+		{"network_profile.network_policy", 0},
+		{"location", 0},
+	}
+	for _, test := range tests {
+		if actual := attributeExpressions(aks.Expressions, test.attributePath); len(actual) != test.expectedCount {
+			t.Errorf("Expected %d expressions for '%s' but got %d", test.expectedCount, test.attributePath, len(actual))
+		}
+	}
+}
+
+func TestReferencesAddress(t *testing.T) {
+	references := []string{"azurerm_resource_group.rg.name", "var.subnets[0]", "module.network.subnet_id"}
+
+	tests := []struct {
+		address    string
+		shouldPass bool
+	}{
+		{"azurerm_resource_group.rg.name", true},
+		{"azurerm_resource_group.rg", true},
+		{"var.subnets", true},
+		{"module.network", true},
+		{"azurerm_resource_group.r", false}, // prefix of the name only
+		{"var.name", false},
+	}
+	for _, test := range tests {
+		if actual := referencesAddress(references, test.address); actual != test.shouldPass {
+			t.Errorf("Expected references to contain '%s' (%t) but got %t", test.address, test.shouldPass, actual)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Package unit This file provides plan validations for `import` blocks, which bring existing resources under the
+management of terraform. An import configuration is only correct if it targets the expected resources and the
+configuration of each imported resource matches the resource exactly, so that the import does not change anything.
+*/
+package unit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// ImportsResource Validates that the plan imports the resource with the ID to the address, and that the imported
+// resource is otherwise unchanged. Other resources may be imported as well
+func ImportsResource(address string, id string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' is imported from ID '%s'", address, id)
+		if violations := importViolations(plan, map[string]string{address: id}, false); len(violations) > 0 {
+			t.Fatalf("Plan unexpectedly did not import resources as expected:\n\t- %s", strings.Join(violations, "\n\t- "))
+		}
+	}
+}
+
+// ImportsResources Validates that the plan imports exactly the resources with the IDs (values) to the addresses (keys),
+// and that every imported resource is otherwise unchanged. Every mismatch is reported before the test is stopped
+func ImportsResources(imports map[string]string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating %d imported resource(s) in plan", len(imports))
+		if violations := importViolations(plan, imports, true); len(violations) > 0 {
+			t.Fatalf("Plan unexpectedly did not import resources as expected:\n\t- %s", strings.Join(violations, "\n\t- "))
+		}
+	}
+}
+
+// returns a description of every expected import that is missing, targets another ID or changes the imported
+// resource, as well as every import that was not expected if the imports are exhaustive
+func importViolations(plan tfjson.Plan, imports map[string]string, isExhaustive bool) []string {
+	changes := make(map[string]*tfjson.ResourceChange)
+	for _, change := range plan.ResourceChanges {
+		if change != nil && change.Change != nil {
+			changes[change.Address] = change
+		}
+	}
+
+	var violations []string
+	for address, id := range imports {
+		change, isFound := changes[address]
+		switch {
+		case !isFound:
+			violations = append(violations, fmt.Sprintf("'%s' could not be found", address))
+		case change.Change.Importing == nil:
+			violations = append(violations, fmt.Sprintf(
+				"'%s' is not imported and will be %v", address, change.Change.Actions))
+		case change.Change.Importing.ID != id:
+			violations = append(violations, fmt.Sprintf(
+				"'%s' is imported from ID '%s' instead of '%s'", address, change.Change.Importing.ID, id))
+		case !change.Change.Actions.NoOp():
+			violations = append(violations, fmt.Sprintf(
+				"'%s' is imported but will also be %v%s", address, change.Change.Actions, actionReason(change)))
+		}
+	}
+
+	for address, change := range changes {
+		if _, isExpected := imports[address]; isExhaustive && !isExpected && change.Change.Importing != nil {
+			violations = append(violations, fmt.Sprintf(
+				"'%s' is unexpectedly imported from ID '%s'", address, change.Change.Importing.ID))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const importPlanJSON = `{
+	"format_version": "1.2",
+	"resource_changes": [{
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["no-op"], "importing": {"id": "/subscriptions/0000/resourceGroups/rg"}}
+	}, {
+		"address": "azurerm_virtual_network.vnet",
+		"mode": "managed",
+		"change": {"actions": ["update"], "importing": {"id": "/vnet"}}
+	}, {
+		"address": "azurerm_storage_account.sa",
+		"mode": "managed",
+		"change": {"actions": ["no-op"], "importing": {"id": "/sa"}}
+	}, {
+		"address": "azurerm_subnet.subnet",
+		"mode": "managed",
+		"change": {"actions": ["create"]}
+	}]
+}`
+
+var importTests = []struct {
+	imports            map[string]string
+	expectedViolations []string
+}{
+	{
+		map[string]string{
+			"azurerm_resource_group.rg":    "/subscriptions/0000/resourceGroups/rg",
+			"azurerm_virtual_network.vnet": "/vnet",
+			"azurerm_storage_account.sa":   "/sa",
+		},
+		[]string{"'azurerm_virtual_network.vnet' is imported but will also be [update]"},
+	}, {
+		map[string]string{
+			"azurerm_resource_group.rg":    "/rg",
+			"azurerm_virtual_network.vnet": "/vnet",
+			"azurerm_storage_account.sa":   "/sa",
+			"azurerm_subnet.subnet":        "/subnet",
+			"azurerm_public_ip.ip":         "/ip",
+		},
+		[]string{
+			"'azurerm_public_ip.ip' could not be found",
+			"'azurerm_resource_group.rg' is imported from ID '/subscriptions/0000/resourceGroups/rg' instead of '/rg'",
+			"'azurerm_subnet.subnet' is not imported and will be [create]",
+			"'azurerm_virtual_network.vnet' is imported but will also be [update]",
+		},
+	}, {
+		map[string]string{"azurerm_storage_account.sa": "/sa"},
+		[]string{
+			"'azurerm_resource_group.rg' is unexpectedly imported from ID '/subscriptions/0000/resourceGroups/rg'",
+			"'azurerm_virtual_network.vnet' is unexpectedly imported from ID '/vnet'",
+		},
+	},
+}
+
+func TestImportViolations(t *testing.T) {
+	plan := jsonToPlan(t, importPlanJSON)
+
+	for _, test := range importTests {
+		violations := importViolations(plan, test.imports, true)
+		if strings.Join(violations, "\n") != strings.Join(test.expectedViolations, "\n") {
+			t.Errorf("Expected violations %v but got %v", test.expectedViolations, violations)
+		}
+	}
+}
+
+func TestImportsResourceIgnoresOtherImports(t *testing.T) {
+	plan := jsonToPlan(t, importPlanJSON)
+
+	ImportsResource("azurerm_storage_account.sa", "/sa")(t, plan)
+	ImportsResource("azurerm_resource_group.rg", "/subscriptions/0000/resourceGroups/rg")(t, plan)
+
+	violations := importViolations(plan, map[string]string{"azurerm_storage_account.sa": "/vnet"}, false)
+	if strings.Join(violations, "\n") != "'azurerm_storage_account.sa' is imported from ID '/sa' instead of '/vnet'" {
+		t.Errorf("Expected only the violation of the single import but got %v", violations)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Package unit This file provides plan validations for the instances that a resource with `count` or `for_each` expands
+to. A `for_each` map that loses or renames a key does not fail the plan of a new deployment, but destroys the
+resource of that key once the template is applied to an existing deployment.
+*/
+package unit
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+)
+
+// ResourceInstanceKeys Validates that the resource expands to exactly the instance keys, which are `for_each` keys
+// (strings) or `count` indexes (ints). For example, `azurerm_subnet.this` with keys "app" and "data" validates
+// that the plan contains `azurerm_subnet.this["app"]` and `azurerm_subnet.this["data"]` and no other instance.
+// Instances that the plan only destroys are not counted
+func ResourceInstanceKeys(address string, keys ...interface{}) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' expands to %d instance(s)", address, len(keys))
+		if violations := instanceKeyViolations(plan, address, keys); len(violations) > 0 {
+			t.Fatalf("'%s' unexpectedly did not expand to the expected instances:\n\t- %s",
+				address, strings.Join(violations, "\n\t- "))
+		}
+	}
+}
+
+// returns a description of every expected instance key that is missing and every instance key that was not expected
+func instanceKeyViolations(plan tfjson.Plan, address string, keys []interface{}) []string {
+	actual := make(map[string]bool)
+	var addresses []string
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil || change.Change.Actions.Delete() {
+			continue
+		}
+		instanceKey := formatInstanceKey(change.Index)
+		if strings.TrimSuffix(change.Address, instanceKey) != address {
+			addresses = append(addresses, strings.TrimSuffix(change.Address, instanceKey))
+			continue
+		}
+		actual[instanceKey] = true
+	}
+	if len(actual) == 0 && len(keys) > 0 {
+		return []string{fmt.Sprintf("'%s' could not be found.%s",
+			address, suggest.DidYouMean(address, addresses, maxSuggestions))}
+	}
+
+	expected := make(map[string]bool)
+	for _, key := range keys {
+		expected[formatInstanceKey(normalizeJSONValue(key))] = true
+	}
+
+	var violations []string
+	for instanceKey := range expected {
+		if !actual[instanceKey] {
+			violations = append(violations, fmt.Sprintf("'%s%s' could not be found", address, instanceKey))
+		}
+	}
+	for instanceKey := range actual {
+		if !expected[instanceKey] {
+			violations = append(violations, fmt.Sprintf("'%s%s' was not expected", address, instanceKey))
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+// formats an instance key the way terraform does in addresses, e.g. `[0]` or `["app"]`. An empty string is returned
+// for resources without `count` or `for_each`
+func formatInstanceKey(index interface{}) string {
+	switch typedIndex := index.(type) {
+	case nil:
+		return ""
+	case string:
+		return "[" + strconv.Quote(typedIndex) + "]"
+	case float64:
+		return "[" + strconv.FormatFloat(typedIndex, 'f', -1, 64) + "]"
+	default:
+		return fmt.Sprintf("[%v]", typedIndex)
+	}
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const instancesPlanJSON = `{
+	"format_version": "1.2",
+	"resource_changes": [{
+		"address": "azurerm_subnet.this[\"app\"]",
+		"mode": "managed",
+		"index": "app",
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "azurerm_subnet.this[\"data\"]",
+		"mode": "managed",
+		"index": "data",
+		"change": {"actions": ["no-op"]}
+	}, {
+		"address": "azurerm_subnet.this[\"web\"]",
+		"mode": "managed",
+		"index": "web",
+		"change": {"actions": ["delete"]}
+	}, {
+		"address": "module.network[\"a\"].azurerm_public_ip.ip[0]",
+		"mode": "managed",
+		"index": 0,
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "module.network[\"a\"].azurerm_public_ip.ip[1]",
+		"mode": "managed",
+		"index": 1,
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["create"]}
+	}]
+}`
+
+var instanceKeyTests = []struct {
+	address            string
+	keys               []interface{}
+	expectedViolations []string
+}{
+	{"azurerm_subnet.this", []interface{}{"app", "data"}, nil},
+	{`module.network["a"].azurerm_public_ip.ip`, []interface{}{0, 1}, nil},
+	{"azurerm_storage_account.sa", nil, nil},
+	{
+		"azurerm_subnet.this",
+		[]interface{}{"app", "web"},
+		[]string{
+			`'azurerm_subnet.this["data"]' was not expected`,
+			`'azurerm_subnet.this["web"]' could not be found`,
+		},
+	}, {
+		`module.network["a"].azurerm_public_ip.ip`,
+		[]interface{}{0},
+		[]string{`'module.network["a"].azurerm_public_ip.ip[1]' was not expected`},
+	}, {
+		"azurerm_resource_group.rg",
+		[]interface{}{"primary"},
+		[]string{
+			`'azurerm_resource_group.rg' was not expected`,
+			`'azurerm_resource_group.rg["primary"]' could not be found`,
+		},
+	}, {
+		"azurerm_subnet.ths",
+		[]interface{}{"app"},
+		[]string{"'azurerm_subnet.ths' could not be found. Did you mean 'azurerm_subnet.this'?"},
+	},
+}
+
+func TestInstanceKeyViolations(t *testing.T) {
+	plan := jsonToPlan(t, instancesPlanJSON)
+
+	for _, test := range instanceKeyTests {
+		violations := instanceKeyViolations(plan, test.address, test.keys)
+		if strings.Join(violations, "\n") != strings.Join(test.expectedViolations, "\n") {
+			t.Errorf("Expected violations for '%s':\n%s\nbut got:\n%s", test.address,
+				strings.Join(test.expectedViolations, "\n"), strings.Join(violations, "\n"))
+		}
+	}
+}
+
+func TestFormatInstanceKey(t *testing.T) {
+	tests := []struct {
+		index    interface{}
+		expected string
+	}{
+		{nil, ""},
+		{"app", `["app"]`},
+		{float64(2), "[2]"},
+		{3, "[3]"},
+	}
+	for _, test := range tests {
+		if actual := formatInstanceKey(test.index); actual != test.expected {
+			t.Errorf("Expected instance key '%s' for %v but got '%s'", test.expected, test.index, actual)
+		}
+	}
+}
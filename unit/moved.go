@@ -0,0 +1,82 @@
+/*
+Package unit This file provides plan validations for refactors that move resources to new addresses, such as `moved`
+blocks. A refactor is only safe if terraform recognizes the move, so that the resource is neither destroyed nor
+recreated.
+*/
+package unit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+// MovedResource Validates that the resource moved from the old address to the new address and that the move is a
+// no-op or an in-place update rather than a replacement
+func MovedResource(fromAddress string, toAddress string) TerraformPlanValidation {
+	return MovedResources(map[string]string{fromAddress: toAddress})
+}
+
+// MovedResources Validates every move from an old address (key) to a new address (value), as described by
+// `MovedResource`. Every failed move is reported before the test is stopped
+func MovedResources(moves map[string]string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating %d moved resource(s) in plan", len(moves))
+		if violations := moveViolations(plan, moves); len(violations) > 0 {
+			t.Fatalf("Plan unexpectedly did not move resources safely:\n\t- %s", strings.Join(violations, "\n\t- "))
+		}
+	}
+}
+
+// returns a description of every move that terraform does not plan as a no-op or an in-place update
+func moveViolations(plan tfjson.Plan, moves map[string]string) []string {
+	changes := make(map[string]*tfjson.ResourceChange)
+	for _, change := range plan.ResourceChanges {
+		if change != nil && change.Change != nil {
+			changes[change.Address] = change
+		}
+	}
+
+	fromAddresses := make([]string, 0, len(moves))
+	for fromAddress := range moves {
+		fromAddresses = append(fromAddresses, fromAddress)
+	}
+	sort.Strings(fromAddresses)
+
+	var violations []string
+	for _, fromAddress := range fromAddresses {
+		toAddress := moves[fromAddress]
+
+		if change, isFound := changes[fromAddress]; isFound {
+			violations = append(violations, fmt.Sprintf(
+				"'%s' is still planned at its old address with actions %v", fromAddress, change.Change.Actions))
+		}
+
+		change, isFound := changes[toAddress]
+		switch {
+		case !isFound:
+			violations = append(violations, fmt.Sprintf("'%s' could not be found", toAddress))
+		case change.PreviousAddress == "":
+			violations = append(violations, fmt.Sprintf(
+				"'%s' was not moved from '%s' and will be %v", toAddress, fromAddress, change.Change.Actions))
+		case change.PreviousAddress != fromAddress:
+			violations = append(violations, fmt.Sprintf(
+				"'%s' was moved from '%s' instead of '%s'", toAddress, change.PreviousAddress, fromAddress))
+		case !change.Change.Actions.NoOp() && !change.Change.Actions.Update():
+			violations = append(violations, fmt.Sprintf(
+				"'%s' was moved from '%s' but will be %v%s", toAddress, fromAddress, change.Change.Actions, actionReason(change)))
+		}
+	}
+	return violations
+}
+
+// describes the reason terraform gives for an action, if any
+func actionReason(change *tfjson.ResourceChange) string {
+	if change.ActionReason == tfjson.ActionReasonNone {
+		return ""
+	}
+	return fmt.Sprintf(" (reason: %s)", change.ActionReason)
+}
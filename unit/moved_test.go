@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const movedPlanJSON = `{
+	"format_version": "1.2",
+	"resource_changes": [{
+		"address": "module.network.azurerm_virtual_network.vnet",
+		"previous_address": "azurerm_virtual_network.vnet",
+		"mode": "managed",
+		"change": {"actions": ["no-op"]}
+	}, {
+		"address": "module.network.azurerm_subnet.subnet[\"app\"]",
+		"previous_address": "azurerm_subnet.subnet[0]",
+		"mode": "managed",
+		"change": {"actions": ["update"]}
+	}, {
+		"address": "azurerm_public_ip.ip",
+		"previous_address": "azurerm_public_ip.old",
+		"mode": "managed",
+		"action_reason": "replace_because_cannot_update",
+		"change": {"actions": ["delete", "create"]}
+	}, {
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "azurerm_resource_group.old",
+		"mode": "managed",
+		"change": {"actions": ["delete"]}
+	}]
+}`
+
+var moveTests = []struct {
+	fromAddress       string
+	toAddress         string
+	expectedViolation string
+}{
+	{"azurerm_virtual_network.vnet", "module.network.azurerm_virtual_network.vnet", ""},
+	{"azurerm_subnet.subnet[0]", `module.network.azurerm_subnet.subnet["app"]`, ""},
+	{"azurerm_public_ip.old", "azurerm_public_ip.ip", "will be [delete create] (reason: replace_because_cannot_update)"},
+	{"azurerm_subnet.subnet[1]", `module.network.azurerm_subnet.subnet["app"]`, "instead of 'azurerm_subnet.subnet[1]'"},
+	{"azurerm_resource_group.old", "azurerm_resource_group.rg", "is still planned at its old address"},
+	{"azurerm_resource_group.missing", "azurerm_resource_group.new", "'azurerm_resource_group.new' could not be found"},
+}
+
+func TestMoveViolations(t *testing.T) {
+	plan := jsonToPlan(t, movedPlanJSON)
+
+	for _, test := range moveTests {
+		violations := moveViolations(plan, map[string]string{test.fromAddress: test.toAddress})
+		if test.expectedViolation == "" {
+			if len(violations) > 0 {
+				t.Errorf("Expected move from '%s' to be valid but got %v", test.fromAddress, violations)
+			}
+			continue
+		}
+		if len(violations) == 0 || !strings.Contains(violations[0], test.expectedViolation) {
+			t.Errorf("Expected move from '%s' to be reported with '%s' but got %v", test.fromAddress, test.expectedViolation, violations)
+		}
+	}
+}
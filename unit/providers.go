@@ -0,0 +1,187 @@
+/*
+Package unit This file provides plan validations for the providers that resources and modules use. Templates that
+deploy to multiple regions do so through provider aliases, and a wrong `provider` argument silently deploys resources
+to the wrong region.
+
+Provider configurations are identified by the name and alias of the provider, e.g. `azurerm` or `azurerm.westus`. A
+provider configuration that is declared in a child module is prefixed with the module address and a colon, e.g.
+`module.network:azurerm`.
+*/
+package unit
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+)
+
+// ResourceUsesProvider Validates that every instance of the resource is managed by the provider, which is either the
+// fully qualified name, e.g. `registry.terraform.io/hashicorp/azurerm`, or the type of the provider, e.g. `azurerm`
+func ResourceUsesProvider(address string, provider string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' uses provider '%s'", address, provider)
+		if err := verifyResourceProvider(plan, address, provider); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ResourceUsesProviderConfig Validates that the resource uses the provider configuration, e.g. `azurerm.westus`
+func ResourceUsesProviderConfig(address string, providerConfig string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s' uses provider configuration '%s'", address, providerConfig)
+		resource := configResourceOrFail(t, plan, configAddress(address))
+		if actual := resourceProviderConfig(plan, resource); actual != providerConfig {
+			t.Fatalf("'%s' unexpectedly used provider configuration '%s' instead of '%s'", address, actual, providerConfig)
+		}
+	}
+}
+
+// ModuleUsesProviderConfig Validates that every resource of the module and its child modules uses the provider
+// configuration, e.g. `azurerm.westus`. Every resource that uses another provider configuration is reported
+func ModuleUsesProviderConfig(moduleAddress string, providerConfig string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that the resources of '%s' use provider configuration '%s'", moduleAddress, providerConfig)
+		violations, err := moduleProviderConfigViolations(plan, configAddress(moduleAddress), providerConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(violations) > 0 {
+			t.Fatalf("Resources of '%s' unexpectedly did not use provider configuration '%s':\n\t- %s",
+				moduleAddress, providerConfig, strings.Join(violations, "\n\t- "))
+		}
+	}
+}
+
+// ProviderConfigArgumentValue Validates that an argument of the provider configuration, such as `location` or
+// `region`, resolves to the expected value. The argument must be a constant or reference a variable of the template
+func ProviderConfigArgumentValue(providerConfig string, argument string, expected interface{}) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that argument '%s' of provider configuration '%s' is '%v'", argument, providerConfig, expected)
+		actual, err := providerConfigArgumentValue(plan, providerConfig, argument)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, normalizeJSONValue(expected)) {
+			t.Fatalf("Argument '%s' of provider configuration '%s' unexpectedly resolved to '%v' instead of '%v'",
+				argument, providerConfig, actual, expected)
+		}
+	}
+}
+
+// returns an error if an instance of the resource is managed by another provider, or if there is no instance
+func verifyResourceProvider(plan tfjson.Plan, address string, provider string) error {
+	var addresses []string
+	isFound := false
+	for _, change := range plan.ResourceChanges {
+		if change == nil {
+			continue
+		}
+		if change.Address != address && configAddress(change.Address) != address {
+			addresses = append(addresses, change.Address)
+			continue
+		}
+
+		isFound = true
+		if change.ProviderName != provider && !strings.HasSuffix(change.ProviderName, "/"+provider) {
+			return fmt.Errorf("'%s' unexpectedly used provider '%s' instead of '%s'", change.Address, change.ProviderName, provider)
+		}
+	}
+	if !isFound {
+		return fmt.Errorf("Unexpectedly could not find resource '%s' in the plan.%s",
+			address, suggest.DidYouMean(address, addresses, maxSuggestions))
+	}
+	return nil
+}
+
+// returns a description of every resource of the module that uses another provider configuration
+func moduleProviderConfigViolations(plan tfjson.Plan, moduleAddress string, providerConfig string) ([]string, error) {
+	isFound := false
+	var violations []string
+	for address, resource := range configResources(configRootModule(plan), "") {
+		if !strings.HasPrefix(address, moduleAddress+".") {
+			continue
+		}
+
+		isFound = true
+		if actual := resourceProviderConfig(plan, resource); actual != providerConfig {
+			violations = append(violations, fmt.Sprintf("'%s' uses provider configuration '%s'", address, actual))
+		}
+	}
+	if !isFound {
+		return nil, fmt.Errorf("Unexpectedly could not find any resources of module '%s' in the configuration", moduleAddress)
+	}
+
+	sort.Strings(violations)
+	return violations, nil
+}
+
+// returns the provider configuration that the resource uses. Terraform resolves the provider configuration key of a
+// resource to the configuration that is passed to its module, so it is looked up in the provider configurations of
+// the plan. The key itself is returned for providers without a configuration block, e.g. `azurerm`
+func resourceProviderConfig(plan tfjson.Plan, resource *tfjson.ConfigResource) string {
+	if plan.Config != nil {
+		if config, isFound := plan.Config.ProviderConfigs[resource.ProviderConfigKey]; isFound && config != nil {
+			return providerConfigName(config)
+		}
+	}
+	return resource.ProviderConfigKey
+}
+
+// returns the name that identifies a provider configuration, e.g. `azurerm.westus` or `module.network:azurerm`
+func providerConfigName(config *tfjson.ProviderConfig) string {
+	name := config.Name
+	if config.Alias != "" {
+		name = name + "." + config.Alias
+	}
+	if config.ModuleAddress != "" {
+		name = config.ModuleAddress + ":" + name
+	}
+	return name
+}
+
+// returns the value that an argument of the provider configuration resolves to, which is either a constant or the
+// resolved value of a variable of the template
+func providerConfigArgumentValue(plan tfjson.Plan, providerConfig string, argument string) (interface{}, error) {
+	var config *tfjson.ProviderConfig
+	var names []string
+	if plan.Config != nil {
+		for _, candidate := range plan.Config.ProviderConfigs {
+			if candidate == nil {
+				continue
+			}
+			if providerConfigName(candidate) == providerConfig {
+				config = candidate
+				break
+			}
+			names = append(names, providerConfigName(candidate))
+		}
+	}
+	if config == nil {
+		return nil, fmt.Errorf("Unexpectedly could not find provider configuration '%s'.%s",
+			providerConfig, suggest.DidYouMean(providerConfig, names, maxSuggestions))
+	}
+
+	expression, isFound := config.Expressions[argument]
+	if !isFound || expression == nil || expression.ExpressionData == nil {
+		return nil, fmt.Errorf("Provider configuration '%s' unexpectedly did not set argument '%s'", providerConfig, argument)
+	}
+	if expression.ConstantValue != nil && expression.ConstantValue != tfjson.UnknownConstantValue {
+		return expression.ConstantValue, nil
+	}
+	for _, reference := range expression.References {
+		// variables of child modules are not part of the plan, so only root module variables can be resolved
+		if strings.HasPrefix(reference, "var.") && config.ModuleAddress == "" {
+			if variable, isFound := plan.Variables[strings.TrimPrefix(reference, "var.")]; isFound && variable != nil {
+				return variable.Value, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("Argument '%s' of provider configuration '%s' unexpectedly could not be resolved from %v",
+		argument, providerConfig, expression.References)
+}
@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const providersPlanJSON = `{
+	"format_version": "1.2",
+	"variables": {"secondary_location": {"value": "westus"}},
+	"resource_changes": [{
+		"address": "azurerm_resource_group.primary",
+		"mode": "managed",
+		"provider_name": "registry.terraform.io/hashicorp/azurerm",
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "module.secondary.azurerm_subnet.this[\"app\"]",
+		"mode": "managed",
+		"provider_name": "registry.terraform.io/hashicorp/azurerm",
+		"change": {"actions": ["create"]}
+	}, {
+		"address": "module.secondary.azapi_resource.this",
+		"mode": "managed",
+		"provider_name": "registry.terraform.io/azure/azapi",
+		"change": {"actions": ["create"]}
+	}],
+	"configuration": {
+		"provider_config": {
+			"azurerm": {"name": "azurerm", "full_name": "registry.terraform.io/hashicorp/azurerm", "expressions": {"location": {"constant_value": "eastus"}}},
+			"azurerm.secondary": {"name": "azurerm", "full_name": "registry.terraform.io/hashicorp/azurerm", "alias": "secondary", "expressions": {"location": {"references": ["var.secondary_location"]}}},
+			"secondary:azapi": {"name": "azapi", "full_name": "registry.terraform.io/azure/azapi", "module_address": "module.secondary", "expressions": {"location": {"references": ["local.location"]}}}
+		},
+		"root_module": {
+			"resources": [{
+				"address": "azurerm_resource_group.primary",
+				"mode": "managed",
+				"type": "azurerm_resource_group",
+				"name": "primary",
+				"provider_config_key": "azurerm"
+			}],
+			"module_calls": {
+				"secondary": {
+					"source": "./modules/region",
+					"module": {
+						"resources": [{
+							"address": "azurerm_subnet.this",
+							"mode": "managed",
+							"type": "azurerm_subnet",
+							"name": "this",
+							"provider_config_key": "azurerm.secondary"
+						}, {
+							"address": "azapi_resource.this",
+							"mode": "managed",
+							"type": "azapi_resource",
+							"name": "this",
+							"provider_config_key": "secondary:azapi"
+						}]
+					}
+				}
+			}
+		}
+	}
+}`
+
+func TestVerifyResourceProvider(t *testing.T) {
+	plan := jsonToPlan(t, providersPlanJSON)
+
+	tests := []struct {
+		address       string
+		provider      string
+		expectedError string
+	}{
+		{"azurerm_resource_group.primary", "registry.terraform.io/hashicorp/azurerm", ""},
+		{"azurerm_resource_group.primary", "azurerm", ""},
+		{"module.secondary.azurerm_subnet.this", "azurerm", ""},
+		{`module.secondary.azurerm_subnet.this["app"]`, "azurerm", ""},
+		{"module.secondary.azapi_resource.this", "azurerm", "used provider 'registry.terraform.io/azure/azapi' instead of 'azurerm'"},
+		{"azurerm_resource_group.primry", "azurerm", "Did you mean 'azurerm_resource_group.primary'?"},
+	}
+	for _, test := range tests {
+		err := verifyResourceProvider(plan, test.address, test.provider)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("Expected '%s' to use '%s' but got: %s", test.address, test.provider, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("Expected an error containing '%s' but got: %v", test.expectedError, err)
+		}
+	}
+}
+
+func TestResourceProviderConfig(t *testing.T) {
+	plan := jsonToPlan(t, providersPlanJSON)
+	resources := configResources(configRootModule(plan), "")
+
+	expected := map[string]string{
+		"azurerm_resource_group.primary":       "azurerm",
+		"module.secondary.azurerm_subnet.this": "azurerm.secondary",
+		"module.secondary.azapi_resource.this": "module.secondary:azapi",
+	}
+	for address, providerConfig := range expected {
+		if actual := resourceProviderConfig(plan, resources[address]); actual != providerConfig {
+			t.Errorf("Expected '%s' to use '%s' but got '%s'", address, providerConfig, actual)
+		}
+	}
+}
+
+func TestModuleProviderConfigViolations(t *testing.T) {
+	plan := jsonToPlan(t, providersPlanJSON)
+
+	violations, err := moduleProviderConfigViolations(plan, "module.secondary", "azurerm.secondary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "'module.secondary.azapi_resource.this' uses provider configuration 'module.secondary:azapi'"
+	if strings.Join(violations, "\n") != expected {
+		t.Fatalf("Expected violation '%s' but got %v", expected, violations)
+	}
+
+	if _, err := moduleProviderConfigViolations(plan, "module.primary", "azurerm"); err == nil {
+		t.Fatal("Expected an error for a module without resources")
+	}
+}
+
+func TestProviderConfigArgumentValue(t *testing.T) {
+	plan := jsonToPlan(t, providersPlanJSON)
+
+	tests := []struct {
+		providerConfig string
+		argument       string
+		expectedValue  interface{}
+		expectedError  string
+	}{
+		{"azurerm", "location", "eastus", ""},
+		{"azurerm.secondary", "location", "westus", ""},
+		{"azurerm.secondary", "subscription_id", nil, "did not set argument 'subscription_id'"},
+		{"module.secondary:azapi", "location", nil, "could not be resolved from [local.location]"},
+		{"azurerm.secondry", "location", nil, "Did you mean 'azurerm.secondary'?"},
+	}
+	for _, test := range tests {
+		value, err := providerConfigArgumentValue(plan, test.providerConfig, test.argument)
+		if test.expectedError == "" {
+			if err != nil || value != test.expectedValue {
+				t.Errorf("Expected '%s' of '%s' to be '%v' but got '%v' (%v)",
+					test.argument, test.providerConfig, test.expectedValue, value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("Expected an error containing '%s' but got: %v", test.expectedError, err)
+		}
+	}
+}
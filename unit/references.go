@@ -0,0 +1,134 @@
+/*
+Package unit This file provides plan validations for attributes that are linked across resources, such as a network
+security group whose resource group name comes from `azurerm_resource_group.rg.name`. Asserting the link instead of
+a literal value keeps tests working when names change.
+*/
+package unit
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+)
+
+// matches the instance keys of an address, e.g. `[0]` or `["app"]`
+var instanceKeyExpression = regexp.MustCompile(`\[[^\]]*\]`)
+
+// AttributesLinked Validates that an attribute of a resource is set from an attribute of another resource. The link is
+// satisfied either if both values are known in the plan and equal, or if the configuration expression of the attribute
+// references the other attribute. For example:
+//
+//	unit.AttributesLinked("azurerm_network_security_group.nsg", "resource_group_name", "azurerm_resource_group.rg", "name")
+func AttributesLinked(resourceAddress string, attribute string, targetAddress string, targetAttribute string) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		t.Logf("Validating that '%s.%s' is linked to '%s.%s'", resourceAddress, attribute, targetAddress, targetAttribute)
+		if err := verifyAttributesLinked(plan, resourceAddress, attribute, targetAddress, targetAttribute); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// returns nil if the attributes are linked, or an error describing why they are not
+func verifyAttributesLinked(plan tfjson.Plan, resourceAddress string, attribute string, targetAddress string, targetAttribute string) error {
+	value, isKnown, err := plannedAttributeValue(plan, resourceAddress, attribute)
+	if err != nil {
+		return err
+	}
+	targetValue, isTargetKnown, err := plannedAttributeValue(plan, targetAddress, targetAttribute)
+	if err != nil {
+		return err
+	}
+	if isKnown && isTargetKnown && value != nil && reflect.DeepEqual(value, targetValue) {
+		return nil
+	}
+
+	if reference, isReferenceable := relativeReference(resourceAddress, targetAddress+"."+targetAttribute); isReferenceable {
+		resource, isFound := configResources(configRootModule(plan), "")[configAddress(resourceAddress)]
+		if isFound {
+			for _, expression := range attributeExpressions(resource.Expressions, attribute) {
+				if referencesAddress(expression.References, reference) {
+					return nil
+				}
+			}
+		}
+	}
+
+	return fmt.Errorf(
+		"'%s.%s' unexpectedly was not linked to '%s.%s': the planned values are %s and %s, and the configuration does not reference it",
+		resourceAddress, attribute, targetAddress, targetAttribute,
+		describePlannedValue(value, isKnown), describePlannedValue(targetValue, isTargetKnown))
+}
+
+// returns the planned value of a resource attribute and whether it is known. Attributes of nested objects are
+// separated by dots, e.g. `tags.environment`
+func plannedAttributeValue(plan tfjson.Plan, address string, attributePath string) (interface{}, bool, error) {
+	var addresses []string
+	for _, change := range plan.ResourceChanges {
+		if change == nil || change.Change == nil {
+			continue
+		}
+		if change.Address != address {
+			addresses = append(addresses, change.Address)
+			continue
+		}
+
+		var value interface{} = change.Change.After
+		var unknown interface{} = change.Change.AfterUnknown
+		for _, key := range strings.Split(attributePath, ".") {
+			if unknown == true {
+				break
+			}
+			values, _ := value.(map[string]interface{})
+			unknownValues, _ := unknown.(map[string]interface{})
+			value, unknown = values[key], unknownValues[key]
+		}
+		return value, unknown != true, nil
+	}
+	return nil, false, fmt.Errorf("Unexpectedly could not find resource '%s' in the plan.%s",
+		address, suggest.DidYouMean(address, addresses, maxSuggestions))
+}
+
+// describes a planned value for failure messages
+func describePlannedValue(value interface{}, isKnown bool) string {
+	if !isKnown {
+		return "(known after apply)"
+	}
+	return fmt.Sprintf("'%v'", value)
+}
+
+// returns the address without instance keys, as used in the configuration, e.g. `module.network.azurerm_subnet.this`
+// for `module.network["a"].azurerm_subnet.this[0]`
+func configAddress(address string) string {
+	return instanceKeyExpression.ReplaceAllString(address, "")
+}
+
+// returns the reference to the target as written in the module of the resource, which is only possible if both are
+// declared in the same module. For example, `module.network.azurerm_subnet.this` can reference
+// `module.network.azurerm_virtual_network.vnet.name` as `azurerm_virtual_network.vnet.name`
+func relativeReference(resourceAddress string, target string) (string, bool) {
+	parsed, err := parseResourceAddress(resourceAddress)
+	if err != nil {
+		return "", false
+	}
+
+	modulePrefix := ""
+	if parsed.Module != "" {
+		modulePrefix = configAddress(parsed.Module) + "."
+	}
+	target = configAddress(target)
+	if !strings.HasPrefix(target, modulePrefix) {
+		return "", false
+	}
+
+	reference := strings.TrimPrefix(target, modulePrefix)
+	if strings.HasPrefix(reference, "module.") {
+		// resources declared in a child module cannot be referenced from the parent module
+		return "", false
+	}
+	return reference, true
+}
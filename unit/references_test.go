@@ -0,0 +1,94 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const linkedPlanJSON = `{
+	"format_version": "1.2",
+	"resource_changes": [{
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["create"], "after": {"name": "rg-dev", "id": null}, "after_unknown": {"id": true}}
+	}, {
+		"address": "azurerm_network_security_group.nsg",
+		"mode": "managed",
+		"change": {"actions": ["create"], "after": {"resource_group_name": "rg-dev", "location": "eastus"}}
+	}, {
+		"address": "azurerm_subnet.subnet[0]",
+		"mode": "managed",
+		"change": {"actions": ["create"], "after": {"resource_group_id": null}, "after_unknown": {"resource_group_id": true}}
+	}, {
+		"address": "module.network.azurerm_virtual_network.vnet",
+		"mode": "managed",
+		"change": {"actions": ["create"], "after": {"resource_group_name": "rg-prod"}}
+	}],
+	"configuration": {
+		"root_module": {
+			"resources": [{
+				"address": "azurerm_subnet.subnet",
+				"mode": "managed",
+				"type": "azurerm_subnet",
+				"name": "subnet",
+				"expressions": {"resource_group_id": {"references": ["azurerm_resource_group.rg.id", "azurerm_resource_group.rg"]}}
+			}]
+		}
+	}
+}`
+
+var linkTests = []struct {
+	resourceAddress string
+	attribute       string
+	targetAddress   string
+	targetAttribute string
+	expectedError   string
+}{
+	// linked by equal known values
+	{"azurerm_network_security_group.nsg", "resource_group_name", "azurerm_resource_group.rg", "name", ""},
+	// linked by the configuration while the value is unknown
+	{"azurerm_subnet.subnet[0]", "resource_group_id", "azurerm_resource_group.rg", "id", ""},
+	{"azurerm_network_security_group.nsg", "location", "azurerm_resource_group.rg", "name", "the planned values are 'eastus' and 'rg-dev'"},
+	{"module.network.azurerm_virtual_network.vnet", "resource_group_name", "azurerm_resource_group.rg", "name", "was not linked"},
+	{"azurerm_network_security_group.nsg", "resource_group_name", "azurerm_resource_group.rg", "id", "'rg-dev' and (known after apply)"},
+	{"azurerm_network_security_group.missing", "name", "azurerm_resource_group.rg", "name", "could not find resource 'azurerm_network_security_group.missing'"},
+	{"azurerm_network_security_grp.nsg", "name", "azurerm_resource_group.rg", "name", "Did you mean 'azurerm_network_security_group.nsg'?"},
+}
+
+func TestVerifyAttributesLinked(t *testing.T) {
+	plan := jsonToPlan(t, linkedPlanJSON)
+
+	for _, test := range linkTests {
+		err := verifyAttributesLinked(plan, test.resourceAddress, test.attribute, test.targetAddress, test.targetAttribute)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("Expected '%s.%s' to be linked but got: %s", test.resourceAddress, test.attribute, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("Expected an error containing '%s' but got: %v", test.expectedError, err)
+		}
+	}
+}
+
+func TestRelativeReference(t *testing.T) {
+	tests := []struct {
+		resourceAddress   string
+		target            string
+		expectedReference string
+		isReferenceable   bool
+	}{
+		{"azurerm_subnet.subnet[0]", "azurerm_resource_group.rg.name", "azurerm_resource_group.rg.name", true},
+		{`module.network["a"].azurerm_subnet.this`, `module.network["a"].azurerm_virtual_network.vnet.name`, "azurerm_virtual_network.vnet.name", true},
+		{"module.network.azurerm_subnet.this", "azurerm_resource_group.rg.name", "", false},
+		{"azurerm_subnet.subnet", "module.network.azurerm_virtual_network.vnet.name", "", false},
+	}
+	for _, test := range tests {
+		reference, isReferenceable := relativeReference(test.resourceAddress, test.target)
+		if reference != test.expectedReference || isReferenceable != test.isReferenceable {
+			t.Errorf("Expected reference '%s' (%t) from '%s' but got '%s' (%t)",
+				test.expectedReference, test.isReferenceable, test.resourceAddress, reference, isReferenceable)
+		}
+	}
+}
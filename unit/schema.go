@@ -0,0 +1,291 @@
+/*
+Package unit This file provides validation of the expectations of a fixture against the schemas of the providers used
+by the template. An expected attribute that does not exist, or that has the wrong type, otherwise only surfaces as a
+confusing mismatch once the plan is compared, if at all.
+*/
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// provider schemas keyed by terraform directory, so that `terraform providers schema -json` runs once per test run
+var (
+	providerSchemas     = make(map[string]*tfjson.ProviderSchemas)
+	providerSchemasLock sync.Mutex
+)
+
+// Validates that every attribute path of the expected resources, data sources and prior state exists in the provider
+// schemas, and that every expected value has the type of its attribute
+func validateExpectationsAgainstProviderSchemas(fixture *UnitTestFixture) {
+	schemas := readProviderSchemas(fixture)
+
+	var violations []string
+	for _, resources := range []ResourceDescription{
+		fixture.ExpectedResourceAttributeValues,
+		fixture.ExpectedDataSourceAttributeValues,
+		fixture.PriorState,
+	} {
+		violations = append(violations, schemaViolations(schemas, resources)...)
+	}
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		fixture.GoTest.Fatalf("Expectations unexpectedly did not match the provider schemas:\n\t- %s",
+			strings.Join(violations, "\n\t- "))
+	}
+}
+
+// returns the provider schemas of the terraform directory, running `terraform providers schema -json` if they have
+// not been read yet. The directory must be initialized
+func readProviderSchemas(fixture *UnitTestFixture) *tfjson.ProviderSchemas {
+	providerSchemasLock.Lock()
+	defer providerSchemasLock.Unlock()
+
+	if schemas, isFound := providerSchemas[fixture.TfOptions.TerraformDir]; isFound {
+		return schemas
+	}
+
+	// Note: the output is read directly for the same reason as in `parseTerraformPlan`, schemas are large
+	cmd := exec.Command("terraform", "providers", "schema", "-json")
+	cmd.Dir = fixture.TfOptions.TerraformDir
+	jsonBytes, cmdErr := cmd.Output()
+	if cmdErr != nil {
+		fixture.GoTest.Fatal(cmdErr)
+	}
+
+	var schemas tfjson.ProviderSchemas
+	if jsonErr := json.Unmarshal(jsonBytes, &schemas); jsonErr != nil {
+		fixture.GoTest.Fatal(jsonErr)
+	}
+	providerSchemas[fixture.TfOptions.TerraformDir] = &schemas
+	return &schemas
+}
+
+// returns a description of every resource whose type is not in the provider schemas, and of every attribute of the
+// resources that does not exist or whose expected value has the wrong type
+func schemaViolations(schemas *tfjson.ProviderSchemas, resources ResourceDescription) []string {
+	var violations []string
+	for address, attributes := range resources {
+		parsedAddress, err := parseResourceAddress(address)
+		if err != nil {
+			violations = append(violations, err.Error())
+			continue
+		}
+
+		block, resourceTypes := resourceSchemaBlock(schemas, parsedAddress)
+		if block == nil {
+			violations = append(violations, fmt.Sprintf("'%s' has type '%s' which no provider defines.%s",
+				address, parsedAddress.Type, suggest.DidYouMean(parsedAddress.Type, resourceTypes, maxSuggestions)))
+			continue
+		}
+
+		values := make(map[string]interface{})
+		for name, value := range attributes {
+			values[name] = value
+		}
+		violations = append(violations, blockViolations(block, values, address)...)
+	}
+	return violations
+}
+
+// returns the schema block of the resource type with the mode of the address, or nil and every resource type with
+// that mode if no provider defines it
+func resourceSchemaBlock(schemas *tfjson.ProviderSchemas, address resourceAddress) (*tfjson.SchemaBlock, []string) {
+	var resourceTypes []string
+	if schemas == nil {
+		return nil, resourceTypes
+	}
+
+	for _, provider := range schemas.Schemas {
+		if provider == nil {
+			continue
+		}
+		resourceSchemas := provider.ResourceSchemas
+		if address.Mode == "data" {
+			resourceSchemas = provider.DataSourceSchemas
+		}
+		if schema, isFound := resourceSchemas[address.Type]; isFound && schema != nil && schema.Block != nil {
+			return schema.Block, nil
+		}
+		for resourceType := range resourceSchemas {
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+	}
+	return nil, resourceTypes
+}
+
+// returns a description of every value that is not an attribute or nested block of the schema block, or that does
+// not have the type of its attribute or nesting of its block
+func blockViolations(block *tfjson.SchemaBlock, values map[string]interface{}, traversalPath string) []string {
+	var violations []string
+	for name, value := range values {
+		currentTraversalPath := traversalPath + "." + name
+		if attribute, isFound := block.Attributes[name]; isFound && attribute != nil {
+			violations = append(violations, attributeViolations(attribute, value, currentTraversalPath)...)
+			continue
+		}
+		if nestedBlock, isFound := block.NestedBlocks[name]; isFound && nestedBlock != nil && nestedBlock.Block != nil {
+			violations = append(violations, nestingViolations(nestedBlock.NestingMode, value, currentTraversalPath,
+				func(value map[string]interface{}, path string) []string {
+					return blockViolations(nestedBlock.Block, value, path)
+				})...)
+			continue
+		}
+
+		var names []string
+		for attributeName := range block.Attributes {
+			names = append(names, attributeName)
+		}
+		for blockName := range block.NestedBlocks {
+			names = append(names, blockName)
+		}
+		violations = append(violations, fmt.Sprintf("'%s' is not an attribute or block in the schema.%s",
+			currentTraversalPath, suggest.DidYouMean(name, names, maxSuggestions)))
+	}
+	return violations
+}
+
+// returns a description of every part of the value that does not match the type of the attribute
+func attributeViolations(attribute *tfjson.SchemaAttribute, value interface{}, traversalPath string) []string {
+	if nestedType := attribute.AttributeNestedType; nestedType != nil {
+		return nestingViolations(nestedType.NestingMode, value, traversalPath,
+			func(value map[string]interface{}, path string) []string {
+				return blockViolations(&tfjson.SchemaBlock{Attributes: nestedType.Attributes}, value, path)
+			})
+	}
+	return typeViolations(attribute.AttributeType, value, traversalPath)
+}
+
+// returns a description of every part of the value that does not match the nesting mode of a nested block or nested
+// attribute, validating each nested object with the function
+func nestingViolations(
+	nestingMode tfjson.SchemaNestingMode,
+	value interface{},
+	traversalPath string,
+	objectViolations func(value map[string]interface{}, path string) []string,
+) []string {
+	if value == nil {
+		return nil
+	}
+
+	switch nestingMode {
+	case tfjson.SchemaNestingModeSingle, tfjson.SchemaNestingModeGroup:
+		if object, isObject := value.(map[string]interface{}); isObject {
+			return objectViolations(object, traversalPath)
+		}
+	case tfjson.SchemaNestingModeList, tfjson.SchemaNestingModeSet:
+		if list, isList := value.([]interface{}); isList {
+			var violations []string
+			for i, element := range list {
+				elementPath := fmt.Sprintf("%s[%d]", traversalPath, i)
+				if object, isObject := element.(map[string]interface{}); isObject {
+					violations = append(violations, objectViolations(object, elementPath)...)
+				} else if element != nil {
+					violations = append(violations, fmt.Sprintf("'%s' is '%T' instead of an object", elementPath, element))
+				}
+			}
+			return violations
+		}
+	case tfjson.SchemaNestingModeMap:
+		if object, isObject := value.(map[string]interface{}); isObject {
+			var violations []string
+			for key, element := range object {
+				elementPath := fmt.Sprintf("%s[%q]", traversalPath, key)
+				if nestedObject, isObject := element.(map[string]interface{}); isObject {
+					violations = append(violations, objectViolations(nestedObject, elementPath)...)
+				} else if element != nil {
+					violations = append(violations, fmt.Sprintf("'%s' is '%T' instead of an object", elementPath, element))
+				}
+			}
+			return violations
+		}
+	default:
+		return nil
+	}
+	return []string{fmt.Sprintf("'%s' is '%T' which does not match the nesting mode '%s'", traversalPath, value, nestingMode)}
+}
+
+// returns a description of every part of the value that does not match the type
+func typeViolations(attributeType cty.Type, value interface{}, traversalPath string) []string {
+	if value == nil || attributeType == cty.NilType || attributeType == cty.DynamicPseudoType {
+		return nil
+	}
+
+	mismatch := []string{fmt.Sprintf("'%s' is '%T' instead of '%s'", traversalPath, value, attributeType.FriendlyName())}
+	switch {
+	case attributeType == cty.String:
+		if _, isString := value.(string); !isString {
+			return mismatch
+		}
+	case attributeType == cty.Bool:
+		if _, isBool := value.(bool); !isBool {
+			return mismatch
+		}
+	case attributeType == cty.Number:
+		switch value.(type) {
+		case float32, float64, int:
+		default:
+			return mismatch
+		}
+	case attributeType.IsListType(), attributeType.IsSetType(), attributeType.IsTupleType():
+		list, isList := value.([]interface{})
+		if !isList {
+			return mismatch
+		}
+		var violations []string
+		for i, element := range list {
+			elementType := cty.DynamicPseudoType
+			if attributeType.IsTupleType() {
+				if i < attributeType.Length() {
+					elementType = attributeType.TupleElementType(i)
+				}
+			} else {
+				elementType = attributeType.ElementType()
+			}
+			violations = append(violations, typeViolations(elementType, element, fmt.Sprintf("%s[%d]", traversalPath, i))...)
+		}
+		return violations
+	case attributeType.IsMapType():
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return mismatch
+		}
+		var violations []string
+		for key, element := range object {
+			violations = append(violations,
+				typeViolations(attributeType.ElementType(), element, fmt.Sprintf("%s[%q]", traversalPath, key))...)
+		}
+		return violations
+	case attributeType.IsObjectType():
+		object, isObject := value.(map[string]interface{})
+		if !isObject {
+			return mismatch
+		}
+		attributeTypes := attributeType.AttributeTypes()
+		var violations []string
+		for name, element := range object {
+			elementType, isFound := attributeTypes[name]
+			if !isFound {
+				var names []string
+				for attributeName := range attributeTypes {
+					names = append(names, attributeName)
+				}
+				violations = append(violations, fmt.Sprintf("'%s.%s' is not an attribute in the schema.%s",
+					traversalPath, name, suggest.DidYouMean(name, names, maxSuggestions)))
+				continue
+			}
+			violations = append(violations, typeViolations(elementType, element, traversalPath+"."+name)...)
+		}
+		return violations
+	}
+	return nil
+}
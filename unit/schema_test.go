@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+)
+
+const providerSchemasJSON = `{
+	"format_version": "1.0",
+	"provider_schemas": {
+		"registry.terraform.io/hashicorp/azurerm": {
+			"resource_schemas": {
+				"azurerm_virtual_network": {
+					"version": 0,
+					"block": {
+						"attributes": {
+							"name": {"type": "string", "required": true},
+							"address_space": {"type": ["list", "string"], "required": true},
+							"tags": {"type": ["map", "string"], "optional": true},
+							"flow_timeout_in_minutes": {"type": "number", "optional": true},
+							"encryption": {"type": ["object", {"enforcement": "string"}], "optional": true}
+						},
+						"block_types": {
+							"subnet": {
+								"nesting_mode": "set",
+								"block": {"attributes": {"name": {"type": "string", "required": true}}}
+							},
+							"ddos_protection_plan": {
+								"nesting_mode": "single",
+								"block": {"attributes": {"enable": {"type": "bool", "required": true}}}
+							}
+						}
+					}
+				}
+			},
+			"data_source_schemas": {
+				"azurerm_client_config": {
+					"version": 0,
+					"block": {"attributes": {"tenant_id": {"type": "string", "computed": true}}}
+				}
+			}
+		}
+	}
+}`
+
+var schemaTests = []struct {
+	resources          ResourceDescription
+	expectedViolations []string
+}{
+	{
+		ResourceDescription{
+			"module.network.azurerm_virtual_network.vnet[0]": {
+				"name":                    "vnet",
+				"address_space":           []interface{}{"10.0.0.0/16"},
+				"tags":                    map[string]interface{}{"env": "dev"},
+				"flow_timeout_in_minutes": 4,
+				"encryption":              map[string]interface{}{"enforcement": "AllowUnencrypted"},
+				"subnet":                  []interface{}{map[string]interface{}{"name": "app"}},
+				"ddos_protection_plan":    map[string]interface{}{"enable": true},
+			},
+			"data.azurerm_client_config.current": {"tenant_id": nil},
+		},
+		nil,
+	}, {
+		ResourceDescription{
+			"azurerm_virtual_network.vnet": {
+				"nme":                  "vnet",
+				"address_space":        "10.0.0.0/16",
+				"tags":                 map[string]interface{}{"env": true},
+				"encryption":           map[string]interface{}{"enforcment": "AllowUnencrypted"},
+				"subnet":               []interface{}{map[string]interface{}{"name": 1}},
+				"ddos_protection_plan": []interface{}{},
+			},
+		},
+		[]string{
+			"'azurerm_virtual_network.vnet.address_space' is 'string' instead of 'list of string'",
+			"'azurerm_virtual_network.vnet.ddos_protection_plan' is '[]interface {}' which does not match the nesting mode 'single'",
+			"'azurerm_virtual_network.vnet.encryption.enforcment' is not an attribute in the schema. Did you mean 'enforcement'?",
+			"'azurerm_virtual_network.vnet.nme' is not an attribute or block in the schema. Did you mean 'name'?",
+			"'azurerm_virtual_network.vnet.subnet[0].name' is 'int' instead of 'string'",
+			"'azurerm_virtual_network.vnet.tags[\"env\"]' is 'bool' instead of 'string'",
+		},
+	}, {
+		ResourceDescription{
+			"azurerm_virtual_netwrk.vnet":       {},
+			"azurerm_client_config.current":     {},
+			"data.azurerm_client_confg.current": {},
+		},
+		[]string{
+			"'azurerm_client_config.current' has type 'azurerm_client_config' which no provider defines.",
+			"'azurerm_virtual_netwrk.vnet' has type 'azurerm_virtual_netwrk' which no provider defines. Did you mean 'azurerm_virtual_network'?",
+			"'data.azurerm_client_confg.current' has type 'azurerm_client_confg' which no provider defines. Did you mean 'azurerm_client_config'?",
+		},
+	},
+}
+
+func TestSchemaViolations(t *testing.T) {
+	var schemas tfjson.ProviderSchemas
+	if err := json.Unmarshal([]byte(providerSchemasJSON), &schemas); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range schemaTests {
+		violations := schemaViolations(&schemas, test.resources)
+		sort.Strings(violations)
+		if strings.Join(violations, "\n") != strings.Join(test.expectedViolations, "\n") {
+			t.Errorf("Expected violations:\n%s\nbut got:\n%s",
+				strings.Join(test.expectedViolations, "\n"), strings.Join(violations, "\n"))
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Package unit This file provides utilities that seed the test workspace with a prior state before planning. This allows
+unit tests to validate how a template updates existing infrastructure, such as a `moved` refactor, without ever
+applying the template.
+*/
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/config"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+// terraform version recorded in generated state. Newer versions of terraform can read state written by older ones
+const generatedStateTerraformVersion = "1.0.0"
+
+// resourceAddress The parts of an absolute resource instance address, e.g. `module.network.azurerm_subnet.this["app"]`
+type resourceAddress struct {
+	Module   string      // address of the containing module, e.g. `module.network`
+	Mode     string      // `managed` or `data`
+	Type     string      // resource type, e.g. `azurerm_subnet`
+	Name     string      // resource name, e.g. `this`
+	IndexKey interface{} // count index (int) or for_each key (string), if any
+}
+
+// returns true if the fixture seeds the workspace with a prior state
+func hasPriorState(fixture *UnitTestFixture) bool {
+	return fixture.PriorStateFile != "" || len(fixture.PriorState) > 0
+}
+
+// pushes the prior state of the fixture into the current workspace using `terraform state push`
+func seedPriorState(fixture *UnitTestFixture) {
+	statePath := fixture.PriorStateFile
+	if statePath == "" {
+		sources, err := config.ProviderSources(fixture.TfOptions.TerraformDir)
+		if err != nil {
+			fixture.GoTest.Fatalf("Required providers could not be read: %s", err)
+		}
+		stateJSON, err := priorStateToJSON(fixture.PriorState, sources)
+		if err != nil {
+			fixture.GoTest.Fatalf("Prior state could not be generated: %s", err)
+		}
+
+		statePath = filepath.FromSlash(fmt.Sprintf("%s/%s.tfstate", os.TempDir(), random.UniqueId()))
+		defer os.Remove(statePath)
+		if err := ioutil.WriteFile(statePath, stateJSON, 0600); err != nil {
+			fixture.GoTest.Fatal(err)
+		}
+	}
+
+	absoluteStatePath, err := filepath.Abs(statePath)
+	if err != nil {
+		fixture.GoTest.Fatal(err)
+	}
+	terraform.RunTerraformCommand(fixture.GoTest, fixture.TfOptions, "state", "push", absoluteStatePath)
+}
+
+// converts a description of resources into a raw state. The provider of each resource is inferred from the prefix of
+// its type, e.g. `azurerm` for `azurerm_resource_group`, and looked up in the provider sources keyed by local name
+func priorStateToJSON(resources ResourceDescription, sources map[string]string) ([]byte, error) {
+	addresses := make([]string, 0, len(resources))
+	for address := range resources {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	state := tfstate.Raw{
+		Version:          tfstate.SupportedVersion,
+		TerraformVersion: generatedStateTerraformVersion,
+		Serial:           1,
+		Lineage:          "unit-" + random.UniqueId(),
+		Outputs:          map[string]tfstate.RawOutput{},
+		Resources:        []*tfstate.RawResource{},
+	}
+
+	// instances of the same resource, e.g. `azurerm_subnet.this[0]` and `azurerm_subnet.this[1]`, share one entry
+	byResource := make(map[string]*tfstate.RawResource)
+	for _, address := range addresses {
+		parsed, err := parseResourceAddress(address)
+		if err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("%s|%s|%s|%s", parsed.Module, parsed.Mode, parsed.Type, parsed.Name)
+		resource, isFound := byResource[key]
+		if !isFound {
+			resource = &tfstate.RawResource{
+				Module:   parsed.Module,
+				Mode:     tfjson.ResourceMode(parsed.Mode),
+				Type:     parsed.Type,
+				Name:     parsed.Name,
+				Provider: providerAddress(parsed.Type, sources),
+			}
+			byResource[key] = resource
+			state.Resources = append(state.Resources, resource)
+		}
+		resource.Instances = append(resource.Instances, tfstate.RawResourceInstance{
+			IndexKey:   parsed.IndexKey,
+			Attributes: resources[address],
+		})
+	}
+
+	return json.MarshalIndent(state, "", "  ")
+}
+
+// returns the provider configuration address that terraform records for resources of the type. Like terraform, a
+// provider without a source in `required_providers` is assumed to be published by hashicorp
+func providerAddress(resourceType string, sources map[string]string) string {
+	providerType := resourceType
+	if separator := strings.Index(resourceType, "_"); separator >= 0 {
+		providerType = resourceType[:separator]
+	}
+	source, isFound := sources[providerType]
+	if !isFound {
+		source = "registry.terraform.io/hashicorp/" + providerType
+	}
+	return fmt.Sprintf(`provider[%q]`, source)
+}
+
+// parses an absolute resource instance address, e.g. `module.network.data.azurerm_subnet.this["app"]`
+func parseResourceAddress(address string) (resourceAddress, error) {
+	parsed := resourceAddress{Mode: "managed"}
+	steps := splitAddress(address)
+
+	var modules []string
+	for len(steps) >= 2 && steps[0] == "module" {
+		modules = append(modules, "module."+steps[1])
+		steps = steps[2:]
+	}
+	parsed.Module = strings.Join(modules, ".")
+
+	if len(steps) == 3 && steps[0] == "data" {
+		parsed.Mode = "data"
+		steps = steps[1:]
+	}
+	if len(steps) != 2 {
+		return parsed, fmt.Errorf("'%s' is not a valid resource address", address)
+	}
+	parsed.Type = steps[0]
+
+	name := steps[1]
+	if open := strings.Index(name, "["); open >= 0 {
+		if !strings.HasSuffix(name, "]") {
+			return parsed, fmt.Errorf("'%s' has an invalid instance key", address)
+		}
+		key := name[open+1 : len(name)-1]
+		name = name[:open]
+
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			parsed.IndexKey = unquoted
+		} else if index, err := strconv.Atoi(key); err == nil {
+			parsed.IndexKey = index
+		} else {
+			return parsed, fmt.Errorf("'%s' has an invalid instance key", address)
+		}
+	}
+	parsed.Name = name
+	return parsed, nil
+}
+
+// splits an address on the dots that are not part of an instance key, e.g. `module.a["x.y"].b.c` is split into
+// `module`, `a["x.y"]`, `b` and `c`
+func splitAddress(address string) []string {
+	var steps []string
+	start, inKey := 0, false
+	for i, character := range address {
+		switch {
+		case character == '"':
+			inKey = !inKey
+		case character == '.' && !inKey:
+			steps = append(steps, address[start:i])
+			start = i + 1
+		}
+	}
+	return append(steps, address[start:])
+}
+
+// deletes the workspace. Workspaces that were seeded with a prior state still manage resources, so they have to be
+// deleted forcefully
+func deleteWorkspace(fixture *UnitTestFixture, workspaceName string) {
+	args := []string{"workspace", "delete"}
+	if hasPriorState(fixture) {
+		args = append(args, "-force")
+	}
+	terraform.RunTerraformCommand(fixture.GoTest, fixture.TfOptions, append(args, workspaceName)...)
+}
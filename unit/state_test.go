@@ -0,0 +1,118 @@
+package unit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+)
+
+var addressTests = []struct {
+	address    string
+	expected   resourceAddress
+	shouldPass bool
+}{
+	{
+		`azurerm_resource_group.rg`,
+		resourceAddress{Mode: "managed", Type: "azurerm_resource_group", Name: "rg"},
+		true,
+	}, {
+		`data.azurerm_client_config.current`,
+		resourceAddress{Mode: "data", Type: "azurerm_client_config", Name: "current"},
+		true,
+	}, {
+		`module.network.azurerm_subnet.this[1]`,
+		resourceAddress{Module: "module.network", Mode: "managed", Type: "azurerm_subnet", Name: "this", IndexKey: 1},
+		true,
+	}, {
+		`module.network["a.b"].module.subnets.azurerm_subnet.this["app"]`,
+		resourceAddress{Module: `module.network["a.b"].module.subnets`, Mode: "managed", Type: "azurerm_subnet", Name: "this", IndexKey: "app"},
+		true,
+	}, {
+		`azurerm_resource_group`,
+		resourceAddress{},
+		false, // name is missing
+	}, {
+		`azurerm_subnet.this[app]`,
+		resourceAddress{},
+		false, // key is neither a number nor quoted
+	},
+}
+
+func TestParseResourceAddress(t *testing.T) {
+	for _, test := range addressTests {
+		actual, err := parseResourceAddress(test.address)
+		if (err == nil) != test.shouldPass {
+			t.Errorf("Expected parsing '%s' to pass (%t) but got error %v", test.address, test.shouldPass, err)
+			continue
+		}
+		if test.shouldPass && !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected '%s' to be parsed as %+v but got %+v", test.address, test.expected, actual)
+		}
+	}
+}
+
+func TestPriorStateToJSON(t *testing.T) {
+	stateJSON, err := priorStateToJSON(ResourceDescription{
+		"azurerm_subnet.this[1]":              {"id": "/subnets/b"},
+		"azurerm_subnet.this[0]":              {"id": "/subnets/a"},
+		"module.rg.azurerm_resource_group.rg": {"id": "/rg", "location": "eastus"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state tfstate.Raw
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != tfstate.SupportedVersion || state.Lineage == "" {
+		t.Fatalf("Expected a version 4 state with a lineage but got version %d and lineage '%s'", state.Version, state.Lineage)
+	}
+	if len(state.Resources) != 2 {
+		t.Fatalf("Expected instances of the same resource to be grouped into 2 resources but got %d", len(state.Resources))
+	}
+
+	subnet := state.Resources[0]
+	if subnet.Type != "azurerm_subnet" || len(subnet.Instances) != 2 || subnet.Instances[0].IndexKey != float64(0) {
+		t.Errorf("Expected 2 instances of azurerm_subnet.this but got %+v", subnet)
+	}
+	if subnet.Provider != `provider["registry.terraform.io/hashicorp/azurerm"]` {
+		t.Errorf("Expected the provider to be inferred from the resource type but got '%s'", subnet.Provider)
+	}
+
+	resourceGroup := state.Resources[1]
+	if resourceGroup.Module != "module.rg" || resourceGroup.Instances[0].Attributes["location"] != "eastus" {
+		t.Errorf("Expected module.rg.azurerm_resource_group.rg with its attributes but got %+v", resourceGroup)
+	}
+}
+
+func TestPriorStateToJSONRejectsInvalidAddresses(t *testing.T) {
+	if _, err := priorStateToJSON(ResourceDescription{"not-an-address": {}}, nil); err == nil {
+		t.Fatal("Expected an invalid address to be rejected")
+	}
+}
+
+func TestPriorStateToJSONReadsProviderSources(t *testing.T) {
+	stateJSON, err := priorStateToJSON(ResourceDescription{
+		"azapi_resource.rg":         {"id": "/rg"},
+		"azurerm_resource_group.rg": {"id": "/rg"},
+	}, map[string]string{"azapi": "registry.terraform.io/azure/azapi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state tfstate.Raw
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []string{
+		`provider["registry.terraform.io/azure/azapi"]`,
+		`provider["registry.terraform.io/hashicorp/azurerm"]`,
+	} {
+		if state.Resources[i].Provider != expected {
+			t.Errorf("Expected provider '%s' for '%s' but got '%s'", expected, state.Resources[i].Type, state.Resources[i].Provider)
+		}
+	}
+}
@@ -16,6 +16,10 @@ import (
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/hashicorp/terraform-json"
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
+	"github.com/microsoft/terratest-abstraction/internal/tfstate"
+	"github.com/microsoft/terratest-abstraction/internal/variables"
+	"github.com/microsoft/terratest-abstraction/retry"
 )
 
 // ResourceDescription Identifies mappings between resources and attributes
@@ -27,24 +31,50 @@ type TerraformPlanValidation func(goTest *testing.T, plan tfjson.Plan)
 // TerraformCommandStdoutValidation A function that can run an assertion over a terraform command output and exit code
 type TerraformCommandStdoutValidation func(goTest *testing.T, output string, err error)
 
+// EventuallyPlan Wraps a plan assertion so that it is retried according to the policy until it passes. This is useful
+// for assertions that compare the plan against external systems that may not have settled yet. Each attempt runs
+// against a test harness that records its failures, so the assertion accepts a `testing.TB`
+func EventuallyPlan(policy retry.Policy, assertion func(t testing.TB, plan tfjson.Plan)) TerraformPlanValidation {
+	return func(t *testing.T, plan tfjson.Plan) {
+		retry.Eventually(t, policy, func(t testing.TB) {
+			assertion(t, plan)
+		})
+	}
+}
+
 // UnitTestFixture Holds metadata required to execute a unit test against a test against a terraform template
 type UnitTestFixture struct {
 	GoTest                *testing.T         // Go test harness
 	TfOptions             *terraform.Options // Terraform options
 	Workspace             string
-	ExpectedResourceCount int // Expected # of resources that Terraform should create
+	ExpectedResourceCount int // Expected # of managed resources that Terraform should create
 	// map of maps specifying resource <--> attribute <--> attribute value mappings
 	ExpectedResourceAttributeValues ResourceDescription
-	PlanAssertions                  []TerraformPlanValidation          // user-defined plan assertions
-	CommandStdoutAssertions         []TerraformCommandStdoutValidation // user-defined command output assertions
+	ExpectedDataSourceCount         int // Expected # of data sources, whether read during plan or apply. Only validated if greater than zero
+	// map of maps specifying data source <--> attribute <--> attribute value mappings
+	ExpectedDataSourceAttributeValues ResourceDescription
+	// map specifying variable <--> resolved value mappings, after defaults, variable files and environment variables
+	ExpectedVariableValues  map[string]interface{}
+	PlanAssertions          []TerraformPlanValidation          // user-defined plan assertions
+	CommandStdoutAssertions []TerraformCommandStdoutValidation // user-defined command output assertions
+	// state file pushed into the workspace before planning, e.g. `terraform.tfstate` of an existing deployment
+	PriorStateFile string
+	// resources (address <--> attribute <--> attribute value) converted into a state that is pushed into the workspace
+	// before planning. Ignored if `PriorStateFile` is set
+	PriorState ResourceDescription
+	// log variables in `TfOptions.Vars` and `TfOptions.VarFiles` that the template does not declare instead of failing
+	WarnOnUndeclaredVars bool
+	// check every attribute path in the expectations and prior state against `terraform providers schema -json`
+	// before planning, failing on attributes that do not exist or values that have the wrong type
+	ValidateProviderSchemas bool
 }
 
 // RunUnitTests Executes terraform lifecycle events and verifies the correctness of the resulting terraform.
 // The following actions are coordinated:
-//	- Run `terraform init`
-//	- Create new terraform workspace. This helps prevent accidentally deleting resources
-//	- Run `terraform plan`
-//	- Validate terraform plan file.
+//   - Run `terraform init`
+//   - Create new terraform workspace. This helps prevent accidentally deleting resources
+//   - Optionally push the prior state of the fixture into the workspace, so that the plan describes an update
+//   - Plan and validate just like `PlanAndValidate` does
 func RunUnitTests(fixture *UnitTestFixture) {
 	terraform.Init(fixture.GoTest, fixture.TfOptions)
 
@@ -58,28 +88,59 @@ func RunUnitTests(fixture *UnitTestFixture) {
 		fixture.TfOptions,
 		terraform.FormatArgs(fixture.TfOptions, "workspace", "show")...)
 
+	if hasPriorState(fixture) && startingWorkspaceName == workspaceName {
+		// pushing the prior state would overwrite the state of a workspace that is not owned by the test
+		fixture.GoTest.Fatalf("Prior state cannot be pushed into the starting workspace '%s'", workspaceName)
+	}
+
 	terraform.WorkspaceSelectOrNew(fixture.GoTest, fixture.TfOptions, workspaceName)
 	if startingWorkspaceName != workspaceName {
-		defer terraform.RunTerraformCommand(fixture.GoTest, fixture.TfOptions, "workspace", "delete", workspaceName)
+		defer deleteWorkspace(fixture, workspaceName)
 	}
 	defer terraform.WorkspaceSelectOrNew(fixture.GoTest, fixture.TfOptions, startingWorkspaceName)
 
+	if hasPriorState(fixture) {
+		seedPriorState(fixture)
+	}
+
 	tfPlanFilePath := filepath.FromSlash(fmt.Sprintf("%s/%s.plan", os.TempDir(), random.UniqueId()))
 	defer os.Remove(tfPlanFilePath)
 
+	PlanAndValidate(fixture, tfPlanFilePath)
+}
+
+// PlanAndValidate Runs `terraform plan` in the current workspace, saving the plan to the given file, and validates
+// the command output and the plan. The parsed plan is returned so that callers can act on the saved plan file, for
+// example by applying it. If `terraform plan` fails, nil is returned. The following actions are coordinated:
+//   - Verify that every supplied variable is declared by the template
+//   - Optionally validate the expectations against the provider schemas. The directory must be initialized
+//   - Run `terraform plan`
+//   - Validate the command output and terraform plan file
+func PlanAndValidate(fixture *UnitTestFixture, tfPlanFilePath string) *tfjson.Plan {
+	variables.Verify(fixture.GoTest, fixture.TfOptions, fixture.WarnOnUndeclaredVars)
+	if fixture.ValidateProviderSchemas {
+		validateExpectationsAgainstProviderSchemas(fixture)
+	}
+
+	args := []string{"plan", "-input=false", "-out", tfPlanFilePath}
+	if hasPriorState(fixture) {
+		// the seeded resources do not exist, so they must not be refreshed
+		args = append(args, "-refresh=false")
+	}
 	output, err := terraform.RunTerraformCommandE(
 		fixture.GoTest,
 		fixture.TfOptions,
-		terraform.FormatArgs(fixture.TfOptions, "plan", "-input=false", "-out", tfPlanFilePath)...)
+		terraform.FormatArgs(fixture.TfOptions, args...)...)
 	if err != nil && fixture.CommandStdoutAssertions == nil {
 		fixture.GoTest.Fatal(err)
 	}
 	if fixture.CommandStdoutAssertions != nil {
 		validateTerraformCommandStdout(fixture, output, err)
 	}
-	if err == nil {
-		validateTerraformPlanFile(fixture, tfPlanFilePath)
+	if err != nil {
+		return nil
 	}
+	return validateTerraformPlanFile(fixture, tfPlanFilePath)
 }
 
 // Validate a failed terraform command output and error
@@ -92,12 +153,14 @@ func validateTerraformCommandStdout(fixture *UnitTestFixture, output string, err
 }
 
 // Validates a terraform plan file based on the test fixture. The following validations are made:
-//	- The plan is only creating resources, and the number of resources created should match the
-//		parameters from the test fixture. The plan should only create resources because it should
-//		be brand new infrastructure on each PR cycle.
-//	- The resource <--> attribute <--> attribute value mappings match the parameters from the test fixture
-//	- The plan passes any user-defined assertions
-func validateTerraformPlanFile(fixture *UnitTestFixture, tfPlanFilePath string) {
+//   - The plan is only creating resources, and the number of resources created should match the
+//     parameters from the test fixture. The plan should only create resources because it should
+//     be brand new infrastructure on each PR cycle.
+//   - The resource <--> attribute <--> attribute value mappings match the parameters from the test fixture
+//   - The resolved variable values match the parameters from the test fixture, and every variable set in the
+//     terraform options was declared and overrides its default
+//   - The plan passes any user-defined assertions
+func validateTerraformPlanFile(fixture *UnitTestFixture, tfPlanFilePath string) *tfjson.Plan {
 	plan := parseTerraformPlan(fixture, tfPlanFilePath)
 
 	if fixture.ExpectedResourceCount > 0 {
@@ -110,14 +173,38 @@ func validateTerraformPlanFile(fixture *UnitTestFixture, tfPlanFilePath string)
 		validatePlanResourceCount(t, fixture, plan)
 	})
 
+	if fixture.ExpectedDataSourceCount > 0 {
+		fixture.GoTest.Run("Terraform Plan Data Source Count", func(t *testing.T) {
+			validatePlanDataSourceCount(t, fixture, plan)
+		})
+	}
+
 	fixture.GoTest.Run("Terraform Plan Is Not Destructive", func(t *testing.T) {
-		validatePlanHasNoDeletes(t, plan)
+		validatePlanHasNoDeletes(t, fixture, plan)
 	})
 
 	fixture.GoTest.Run("Terraform Plan Key Values", func(t *testing.T) {
 		validatePlanResourceKeyValues(t, fixture, plan)
 	})
 
+	if fixture.ExpectedDataSourceAttributeValues != nil {
+		fixture.GoTest.Run("Terraform Plan Data Source Key Values", func(t *testing.T) {
+			validatePlanDataSourceKeyValues(t, fixture, plan)
+		})
+	}
+
+	if fixture.ExpectedVariableValues != nil {
+		fixture.GoTest.Run("Terraform Plan Variable Values", func(t *testing.T) {
+			validatePlanVariableValues(t, fixture, plan)
+		})
+	}
+
+	if len(fixture.TfOptions.Vars) > 0 {
+		fixture.GoTest.Run("Terraform Plan Variables Are Overridden", func(t *testing.T) {
+			validatePlanVariablesOverridden(t, fixture, plan)
+		})
+	}
+
 	// run user-provided assertions
 	if fixture.PlanAssertions != nil {
 		for i, planAssertion := range fixture.PlanAssertions {
@@ -126,6 +213,8 @@ func validateTerraformPlanFile(fixture *UnitTestFixture, tfPlanFilePath string)
 			})
 		}
 	}
+
+	return &plan
 }
 
 func parseTerraformPlan(fixture *UnitTestFixture, filePath string) tfjson.Plan {
@@ -164,22 +253,47 @@ func validatePlanNotEmpty(t *testing.T, plan tfjson.Plan) {
 	}
 }
 
-// Validates that the plan has the correct number of resources in it
+// Validates that the plan has the correct number of managed resources in it
 func validatePlanResourceCount(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
-	if len(plan.ResourceChanges) != fixture.ExpectedResourceCount {
+	count := 0
+	for _, resource := range plan.ResourceChanges {
+		if resource.Mode == tfjson.ManagedResourceMode {
+			count++
+		}
+	}
+	if count != fixture.ExpectedResourceCount {
+		t.Fatalf(
+			"Plan unexpectedly had %d managed resources instead of %d", count, fixture.ExpectedResourceCount)
+	}
+}
+
+// Validates that the plan has the correct number of data sources in it
+func validatePlanDataSourceCount(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
+	count := len(planToMap(plan, tfjson.DataResourceMode))
+	if count != fixture.ExpectedDataSourceCount {
 		t.Fatalf(
-			"Plan unexpectedly had %d resources instead of %d", len(plan.ResourceChanges), fixture.ExpectedResourceCount)
+			"Plan unexpectedly had %d data sources instead of %d", count, fixture.ExpectedDataSourceCount)
 	}
 }
 
 // Validates that the plan is not executing any destructive actions
-func validatePlanHasNoDeletes(t *testing.T, plan tfjson.Plan) {
+func validatePlanHasNoDeletes(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
 	// a unit test should never create a destructive action like deleting a resource
 	allowedActions := map[tfjson.Action]bool{tfjson.ActionCreate: true, tfjson.ActionRead: true}
+	if hasPriorState(fixture) {
+		// resources in the prior state may be left unchanged or updated in-place
+		allowedActions[tfjson.ActionNoop] = true
+		allowedActions[tfjson.ActionUpdate] = true
+	}
 	for _, resource := range plan.ResourceChanges {
+		if resource.Change.Importing != nil && resource.Change.Actions.NoOp() {
+			// importing an existing resource without changing it is not destructive
+			continue
+		}
 		for _, action := range resource.Change.Actions {
 			if !allowedActions[action] {
-				t.Fatalf("Plan unexpectedly actions other than `create`: %s", resource.Change.Actions)
+				t.Fatalf("'%s' unexpectedly planned actions %s. Only creates, reads, imports of unchanged resources "+
+					"and updates of resources in the prior state are allowed", resource.Address, resource.Change.Actions)
 			}
 		}
 	}
@@ -188,19 +302,41 @@ func validatePlanHasNoDeletes(t *testing.T, plan tfjson.Plan) {
 // verifies that the attribute value mappings for each resource specified by the client exist
 // as a subset of the actual values defined in the terraform plan.
 func validatePlanResourceKeyValues(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
-	theRealPlanAsMap := planToMap(plan)
+	theRealPlanAsMap := planToMap(plan, tfjson.ManagedResourceMode)
 	theExpectedPlanAsMap := resourceDescriptionToMap(fixture.ExpectedResourceAttributeValues)
 
 	if err := verifyTargetsExistInMap(theRealPlanAsMap, theExpectedPlanAsMap, ""); err != nil {
-		t.Fatal(err)
+		t.Fatalf("Managed resource: %s", err)
+	}
+}
+
+// verifies that the attribute value mappings for each data source specified by the client exist
+// as a subset of the actual values read during plan or planned to be read during apply.
+func validatePlanDataSourceKeyValues(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
+	theRealPlanAsMap := planToMap(plan, tfjson.DataResourceMode)
+	theExpectedPlanAsMap := resourceDescriptionToMap(fixture.ExpectedDataSourceAttributeValues)
+
+	if err := verifyTargetsExistInMap(theRealPlanAsMap, theExpectedPlanAsMap, ""); err != nil {
+		t.Fatalf("Data source: %s", err)
 	}
 }
 
-// transforms the output of `terraform show -json <planfile>` into a generic map
-func planToMap(plan tfjson.Plan) map[string]interface{} {
+// transforms the output of `terraform show -json <planfile>` into a generic map of the resources with the mode.
+// Data sources that are read during plan are only found in the prior state, while data sources that are read
+// during apply are found in the resource changes
+func planToMap(plan tfjson.Plan, mode tfjson.ResourceMode) map[string]interface{} {
 	mp := make(map[string]interface{})
+	if priorState := plan.PriorState; mode == tfjson.DataResourceMode && priorState != nil && priorState.Values != nil {
+		for _, resource := range tfstate.ModuleResources(priorState.Values.RootModule) {
+			if resource.Mode == tfjson.DataResourceMode {
+				mp[resource.Address] = resource.AttributeValues
+			}
+		}
+	}
 	for _, resource := range plan.ResourceChanges {
-		mp[resource.Address] = resource.Change.After
+		if resource.Mode == mode {
+			mp[resource.Address] = resource.Change.After
+		}
 	}
 	return mp
 }
@@ -218,16 +354,21 @@ func HasModuleAddress(moduleAddress string) TerraformPlanValidation {
 	return func(t *testing.T, plan tfjson.Plan) {
 		t.Logf("Validating resouce with module address '%s' present in plan", moduleAddress)
 		var count int
+		var moduleAddresses []string
 		for _, res := range plan.ResourceChanges {
 			if res != nil {
 				if res.ModuleAddress == moduleAddress {
 					count++
 					t.Logf("Found resource '%s' with module address '%s", res.Address, res.ModuleAddress)
 				}
+				if res.ModuleAddress != "" {
+					moduleAddresses = append(moduleAddresses, res.ModuleAddress)
+				}
 			}
 		}
 		if count == 0 {
-			t.Fatalf("Unexpectedly could not find module address '%s'", moduleAddress)
+			t.Fatalf("Unexpectedly could not find module address '%s'.%s",
+				moduleAddress, suggest.DidYouMean(moduleAddress, moduleAddresses, maxSuggestions))
 		}
 	}
 }
@@ -235,19 +376,32 @@ func HasModuleAddress(moduleAddress string) TerraformPlanValidation {
 func HasDataSource(dataSourceAddress string) TerraformPlanValidation {
 	return func(t *testing.T, plan tfjson.Plan) {
 		t.Logf("Validating datasource with address '%s' present in plan", dataSourceAddress)
-		if priorState := plan.PriorState; priorState != nil {
-			if priorState.Values != nil && priorState.Values.RootModule != nil {
-				for _, res := range priorState.Values.RootModule.Resources {
-					if res != nil {
-						if res.Address == dataSourceAddress {
-							t.Logf("Found datasource '%s'", res.Address)
-							return
-						}
-					}
+		var dataSourceAddresses []string
+		if priorState := plan.PriorState; priorState != nil && priorState.Values != nil {
+			for _, res := range tfstate.ModuleResources(priorState.Values.RootModule) {
+				if res.Address == dataSourceAddress {
+					t.Logf("Found datasource '%s'", res.Address)
+					return
+				}
+				if res.Mode == tfjson.DataResourceMode {
+					dataSourceAddresses = append(dataSourceAddresses, res.Address)
 				}
 			}
 		}
-		t.Fatalf("Unexpectedly could not find datasource address '%s'", dataSourceAddress)
+		// data sources that depend on values known only after apply are read during apply instead
+		for _, res := range plan.ResourceChanges {
+			if res != nil && res.Change != nil && res.Mode == tfjson.DataResourceMode && res.Address == dataSourceAddress {
+				if res.Change.Actions.Read() {
+					t.Logf("Found datasource '%s' that will be read during apply", res.Address)
+					return
+				}
+			}
+			if res != nil && res.Mode == tfjson.DataResourceMode {
+				dataSourceAddresses = append(dataSourceAddresses, res.Address)
+			}
+		}
+		t.Fatalf("Unexpectedly could not find datasource address '%s'.%s",
+			dataSourceAddress, suggest.DidYouMean(dataSourceAddress, dataSourceAddresses, maxSuggestions))
 	}
 }
 
@@ -264,13 +418,9 @@ func HasPriorStateResources(resources ResourceDescription) TerraformPlanValidati
 
 func planPriorStateResourcesToMap(plan tfjson.Plan) map[string]interface{} {
 	mp := make(map[string]interface{})
-	if priorState := plan.PriorState; priorState != nil {
-		if priorState.Values != nil && priorState.Values.RootModule != nil {
-			for _, resource := range priorState.Values.RootModule.Resources {
-				if resource != nil {
-					mp[resource.Address] = resource.AttributeValues
-				}
-			}
+	if priorState := plan.PriorState; priorState != nil && priorState.Values != nil {
+		for _, resource := range tfstate.ModuleResources(priorState.Values.RootModule) {
+			mp[resource.Address] = resource.AttributeValues
 		}
 	}
 	return mp
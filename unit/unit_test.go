@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-json"
 )
 
 // the workspace cannot be deleted in the case that the "before" workspace is the same
@@ -52,3 +53,103 @@ func TestTerraformCommandStdout(t *testing.T) {
 
 	RunUnitTests(&testFixture)
 }
+
+const nestedPriorStatePlanJSON = `{
+	"format_version": "1.2",
+	"prior_state": {
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [{"address": "data.azurerm_client_config.current", "mode": "data", "values": {"tenant_id": "0000"}}],
+				"child_modules": [{
+					"address": "module.network",
+					"resources": [{"address": "module.network.data.azurerm_subscription.current", "mode": "data", "values": {"id": "/sub"}}],
+					"child_modules": [{
+						"address": "module.network.module.subnets",
+						"resources": [{"address": "module.network.module.subnets.azurerm_subnet.this", "mode": "managed", "values": {"name": "app"}}]
+					}]
+				}]
+			}
+		}
+	},
+	"resource_changes": [{
+		"address": "module.network.data.azurerm_virtual_network.existing",
+		"mode": "data",
+		"change": {"actions": ["read"]}
+	}]
+}`
+
+func TestHasDataSourceInChildModulesAndDeferredReads(t *testing.T) {
+	plan := jsonToPlan(t, nestedPriorStatePlanJSON)
+
+	HasDataSource("data.azurerm_client_config.current")(t, plan)
+	HasDataSource("module.network.data.azurerm_subscription.current")(t, plan)
+	HasDataSource("module.network.data.azurerm_virtual_network.existing")(t, plan)
+}
+
+func TestPlanPriorStateResourcesToMapIncludesChildModules(t *testing.T) {
+	plan := jsonToPlan(t, nestedPriorStatePlanJSON)
+
+	resources := planPriorStateResourcesToMap(plan)
+	if len(resources) != 3 {
+		t.Fatalf("Expected 3 prior state resources but got %d", len(resources))
+	}
+
+	HasPriorStateResources(ResourceDescription{
+		"module.network.module.subnets.azurerm_subnet.this": {"name": "app"},
+	})(t, plan)
+}
+
+const mixedModePlanJSON = `{
+	"format_version": "1.2",
+	"prior_state": {
+		"format_version": "1.0",
+		"values": {
+			"root_module": {
+				"resources": [
+					{"address": "data.azurerm_client_config.current", "mode": "data", "values": {"tenant_id": "0000"}},
+					{"address": "azurerm_resource_group.existing", "mode": "managed", "values": {"name": "existing"}}
+				]
+			}
+		}
+	},
+	"resource_changes": [{
+		"address": "azurerm_resource_group.rg",
+		"mode": "managed",
+		"change": {"actions": ["create"], "after": {"name": "rg"}}
+	}, {
+		"address": "data.azurerm_subnet.deferred",
+		"mode": "data",
+		"change": {"actions": ["read"], "after": {"name": "app"}}
+	}]
+}`
+
+func TestPlanToMapIsModeAware(t *testing.T) {
+	plan := jsonToPlan(t, mixedModePlanJSON)
+
+	managed := planToMap(plan, tfjson.ManagedResourceMode)
+	if len(managed) != 1 || managed["azurerm_resource_group.rg"] == nil {
+		t.Errorf("Expected only the planned managed resource but got %v", managed)
+	}
+
+	dataSources := planToMap(plan, tfjson.DataResourceMode)
+	if len(dataSources) != 2 || dataSources["data.azurerm_client_config.current"] == nil || dataSources["data.azurerm_subnet.deferred"] == nil {
+		t.Errorf("Expected data sources read during plan and apply but got %v", dataSources)
+	}
+
+	fixture := &UnitTestFixture{
+		ExpectedResourceCount:   1,
+		ExpectedDataSourceCount: 2,
+		ExpectedResourceAttributeValues: ResourceDescription{
+			"azurerm_resource_group.rg": {"name": "rg"},
+		},
+		ExpectedDataSourceAttributeValues: ResourceDescription{
+			"data.azurerm_client_config.current": {"tenant_id": "0000"},
+			"data.azurerm_subnet.deferred":       {"name": "app"},
+		},
+	}
+	validatePlanResourceCount(t, fixture, plan)
+	validatePlanDataSourceCount(t, fixture, plan)
+	validatePlanResourceKeyValues(t, fixture, plan)
+	validatePlanDataSourceKeyValues(t, fixture, plan)
+}
@@ -6,8 +6,13 @@ package unit
 import (
 	"fmt"
 	"reflect"
+
+	"github.com/microsoft/terratest-abstraction/internal/suggest"
 )
 
+// the most candidates that are suggested when an expected key, address or attribute could not be found
+const maxSuggestions = 3
+
 // This function validates that a set of search targets exist in a map. The intended use case is to allow
 // a user of this library to provide a set of *expected values* that should exist within a larger set of
 // *actual values.* In other words, this is a map equality check that does not care about ordering in keys/lists
@@ -17,39 +22,40 @@ import (
 // error describing the mismatch will be returned.
 //
 // Some examples (in pseudo-code):
-//	- Exact Match:
-//		a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
-//		verifyTargetsExistInMap(a, a) --> nil
 //
-//	- Subset Match #1:
-//		a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
-//		b := {"key1":[{"key2" : "foo"}]}
-//		verifyTargetsExistInMap(a, b) --> nil
+//   - Exact Match:
+//     a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
+//     verifyTargetsExistInMap(a, a) --> nil
+//
+//   - Subset Match #1:
+//     a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
+//     b := {"key1":[{"key2" : "foo"}]}
+//     verifyTargetsExistInMap(a, b) --> nil
 //
-//	- Subset Match #2:
-//		a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
-//		b := {"key1":[]}
-//		verifyTargetsExistInMap(a, b) --> nil
+//   - Subset Match #2:
+//     a := {"key1":[{"key2" : "foo"}, {"key2" : "bar"}]}
+//     b := {"key1":[]}
+//     verifyTargetsExistInMap(a, b) --> nil
 //
-//	- Subset Match #3:
-//		a := {"key1":{"key2": "foo", "key3", "bar"}}
-//		b := {"key1":{"key3", "bar"}}
-//		verifyTargetsExistInMap(a, b) --> nil
+//   - Subset Match #3:
+//     a := {"key1":{"key2": "foo", "key3", "bar"}}
+//     b := {"key1":{"key3", "bar"}}
+//     verifyTargetsExistInMap(a, b) --> nil
 //
-//	- Mismatch #1:
-//		a := {"key1":{"key2": "foo", "key3", "bar"}}
-//		b := {"key1":[]}
-//		verifyTargetsExistInMap(a, b) --> ERROR: wrong type for key `key1`
+//   - Mismatch #1:
+//     a := {"key1":{"key2": "foo", "key3", "bar"}}
+//     b := {"key1":[]}
+//     verifyTargetsExistInMap(a, b) --> ERROR: wrong type for key `key1`
 //
-//	- Mismatch #2:
-//		a := {"key1":{"key2": "foo", "key3", "bar"}}
-//		b := {"key1":{"key4": "foo"}}
-//		verifyTargetsExistInMap(a, b) --> ERROR: `key4` not found
+//   - Mismatch #2:
+//     a := {"key1":{"key2": "foo", "key3", "bar"}}
+//     b := {"key1":{"key4": "foo"}}
+//     verifyTargetsExistInMap(a, b) --> ERROR: `key4` not found, did you mean `key2` or `key3`?
 //
-//	- Mismatch #3:
-//		a := {"key1":{"key2": "foo", "key3", "bar"}}
-//		b := {"key1":{"key2": "bar"}}
-//		verifyTargetsExistInMap(a, b) --> ERROR: wrong value for `key2`
+//   - Mismatch #3:
+//     a := {"key1":{"key2": "foo", "key3", "bar"}}
+//     b := {"key1":{"key2": "bar"}}
+//     verifyTargetsExistInMap(a, b) --> ERROR: wrong value for `key2`
 //
 // The algorithm used to do the equality check is to execute a DFS search in parallel for both maps. In the case
 // that a mismatch (the values do not match, or the shape of the maps is different in a way that indicates a mismatch)
@@ -68,7 +74,8 @@ func verifyTargetsExistInMap(dataSource map[string]interface{}, searchTargets ma
 
 		// both maps should contain the target search key
 		if !candidateExists || !targetExists {
-			return fmt.Errorf("Unexpectedly could not find key '%s' at node '%s'", targetKey, currentTraversalPath)
+			return fmt.Errorf("Unexpectedly could not find key '%s' at node '%s'.%s",
+				targetKey, currentTraversalPath, suggest.DidYouMean(targetKey, mapKeys(dataSource), maxSuggestions))
 		}
 
 		// the values for the key should be the same type
@@ -130,6 +137,15 @@ func verifyTargetsExistInList(dataSource []interface{}, searchTargets []interfac
 	return nil
 }
 
+// returns the keys of a map, e.g. the addresses or attribute names that could have been meant instead of a missing key
+func mapKeys(mp map[string]interface{}) []string {
+	keys := make([]string, 0, len(mp))
+	for key := range mp {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 // return true if the values have the same type, false otherwise
 func isSameType(a interface{}, b interface{}) bool {
 	return reflect.TypeOf(a) == reflect.TypeOf(b)
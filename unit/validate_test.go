@@ -2,7 +2,10 @@ package unit
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+
+	"github.com/hashicorp/terraform-json"
 )
 
 var tests = []struct {
@@ -80,6 +83,38 @@ func TestVerifyTargets(t *testing.T) {
 	}
 }
 
+func TestVerifyTargetsSuggestsClosestKeys(t *testing.T) {
+	dataSource := jsonToMap(t, `{
+		"module.network.azurerm_virtual_network.vnet": {"address_space": ["10.0.0.0/16"], "location": "eastus"},
+		"module.network.azurerm_subnet.subnet": {"name": "app"}
+	}`)
+
+	tests := []struct {
+		searchTargetsJSON string
+		expectedError     string
+	}{
+		{
+			`{"module.network.azurerm_virtual_netwrk.vnet": {}}`,
+			"Did you mean 'module.network.azurerm_virtual_network.vnet'?",
+		}, {
+			`{"azurerm_virtual_network.vnet": {}}`,
+			"Did you mean 'module.network.azurerm_virtual_network.vnet'?",
+		}, {
+			`{"module.network.azurerm_virtual_network.vnet": {"addres_space": []}}`,
+			"at node 'module.network.azurerm_virtual_network.vnet.addres_space'. Did you mean 'address_space'?",
+		}, {
+			`{"module.network.azurerm_subnet.subnet": {"tags": {}}}`,
+			"at node 'module.network.azurerm_subnet.subnet.tags'.",
+		},
+	}
+	for _, test := range tests {
+		err := verifyTargetsExistInMap(dataSource, jsonToMap(t, test.searchTargetsJSON), "")
+		if err == nil || !strings.HasSuffix(err.Error(), test.expectedError) {
+			t.Errorf("Expected an error ending with '%s' but got: %v", test.expectedError, err)
+		}
+	}
+}
+
 func jsonToMap(t *testing.T, jsonStr string) map[string]interface{} {
 	var theMap map[string]interface{}
 	if err := json.Unmarshal([]byte(jsonStr), &theMap); err != nil {
@@ -87,3 +122,11 @@ func jsonToMap(t *testing.T, jsonStr string) map[string]interface{} {
 	}
 	return theMap
 }
+
+func jsonToPlan(t *testing.T, jsonStr string) tfjson.Plan {
+	var plan tfjson.Plan
+	if err := json.Unmarshal([]byte(jsonStr), &plan); err != nil {
+		t.Fatalf("Unable to parse plan JSON. Error = `%s`", err)
+	}
+	return plan
+}
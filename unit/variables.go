@@ -0,0 +1,135 @@
+/*
+Package unit This file provides validations of the input variables of a plan, which hold the final value of every
+variable after defaults, variable files and environment variables are applied.
+*/
+package unit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// verifies that the variable values specified by the client exist as a subset of the resolved values in the plan
+func validatePlanVariableValues(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
+	expected := make(map[string]interface{})
+	for name, value := range fixture.ExpectedVariableValues {
+		expected[name] = value
+	}
+	if err := verifyTargetsExistInMap(planVariablesToMap(plan), expected, ""); err != nil {
+		t.Fatalf("Variable: %s", err)
+	}
+}
+
+// verifies that every variable set in the terraform options is declared and was not resolved to its default instead
+// of the value that the test meant to set, which usually indicates a typo in a variable name
+func validatePlanVariablesOverridden(t *testing.T, fixture *UnitTestFixture, plan tfjson.Plan) {
+	if violations := overriddenVariableViolations(plan, fixture.TfOptions.Vars); len(violations) > 0 {
+		t.Fatalf("Variables were unexpectedly not overridden:\n\t- %s", strings.Join(violations, "\n\t- "))
+	}
+}
+
+// transforms the resolved variables of a plan into a generic map
+func planVariablesToMap(plan tfjson.Plan) map[string]interface{} {
+	mp := make(map[string]interface{})
+	for name, variable := range plan.Variables {
+		if variable != nil {
+			mp[name] = variable.Value
+		}
+	}
+	return mp
+}
+
+// returns a description of every variable that is not declared, or that resolved to its default although the test
+// set another value
+func overriddenVariableViolations(plan tfjson.Plan, vars map[string]interface{}) []string {
+	var declared map[string]*tfjson.ConfigVariable
+	if rootModule := configRootModule(plan); rootModule != nil {
+		declared = rootModule.Variables
+	}
+
+	var violations []string
+	for name, value := range vars {
+		resolved, isResolved := plan.Variables[name]
+		if !isResolved || resolved == nil {
+			violations = append(violations, fmt.Sprintf("'%s' is not a variable of the template", name))
+			continue
+		}
+
+		variable, isDeclared := declared[name]
+		if !isDeclared || variable == nil || variable.Default == nil {
+			continue
+		}
+		intended := convertToTypeOf(normalizeJSONValue(value), variable.Default)
+		if reflect.DeepEqual(resolved.Value, variable.Default) && !reflect.DeepEqual(intended, variable.Default) {
+			violations = append(violations, fmt.Sprintf(
+				"'%s' resolved to its default '%v' instead of '%v'", name, variable.Default, value))
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
+
+// converts the intended value of a variable to the primitive type of its default, the way terraform converts a value
+// to the declared type of the variable, e.g. "10" becomes 10 for a `number` variable. The value is returned unchanged
+// if the default is not primitive or the value cannot be converted
+func convertToTypeOf(value interface{}, example interface{}) interface{} {
+	var exampleType cty.Type
+	switch example.(type) {
+	case string:
+		exampleType = cty.String
+	case float64:
+		exampleType = cty.Number
+	case bool:
+		exampleType = cty.Bool
+	default:
+		return value
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	valueType, err := ctyjson.ImpliedType(valueJSON)
+	if err != nil || !valueType.IsPrimitiveType() {
+		return value
+	}
+	ctyValue, err := ctyjson.Unmarshal(valueJSON, valueType)
+	if err != nil {
+		return value
+	}
+	converted, err := convert.Convert(ctyValue, exampleType)
+	if err != nil {
+		return value
+	}
+	convertedJSON, err := ctyjson.Marshal(converted, exampleType)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(convertedJSON, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// converts a value to the generic representation that is parsed from JSON, e.g. numbers become float64
+func normalizeJSONValue(value interface{}) interface{} {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(valueJSON, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"strings"
+	"testing"
+)
+
+const variablesPlanJSON = `{
+	"format_version": "1.2",
+	"variables": {
+		"location": {"value": "eastus"},
+		"name": {"value": "rg-default"},
+		"length": {"value": 15},
+		"size": {"value": 10},
+		"enabled": {"value": true},
+		"tags": {"value": {"env": "dev"}}
+	},
+	"configuration": {
+		"root_module": {
+			"variables": {
+				"location": {"default": "centralus"},
+				"name": {"default": "rg-default"},
+				"length": {"default": 10},
+				"size": {"default": 10},
+				"enabled": {"default": true},
+				"tags": {}
+			}
+		}
+	}
+}`
+
+func TestValidatePlanVariableValues(t *testing.T) {
+	fixture := &UnitTestFixture{
+		ExpectedVariableValues: map[string]interface{}{
+			"location": "eastus",
+			"tags":     map[string]interface{}{"env": "dev"},
+		},
+	}
+	validatePlanVariableValues(t, fixture, jsonToPlan(t, variablesPlanJSON))
+}
+
+var overriddenVariableTests = []struct {
+	vars               map[string]interface{}
+	expectedViolations []string
+}{
+	{
+		map[string]interface{}{"location": "eastus", "length": 15, "tags": map[string]string{"env": "dev"}},
+		nil,
+	}, {
+		// setting a variable to its default is not a violation
+		map[string]interface{}{"name": "rg-default"},
+		nil,
+	}, {
+		// terraform converts values to the declared type of the variable, so "10" sets a number to its default
+		map[string]interface{}{"size": "10", "enabled": "true"},
+		nil,
+	}, {
+		map[string]interface{}{"size": "12"},
+		[]string{"'size' resolved to its default '10' instead of '12'"},
+	}, {
+		map[string]interface{}{"name": "rg-test", "locaton": "eastus"},
+		[]string{
+			"'locaton' is not a variable of the template",
+			"'name' resolved to its default 'rg-default' instead of 'rg-test'",
+		},
+	},
+}
+
+func TestOverriddenVariableViolations(t *testing.T) {
+	plan := jsonToPlan(t, variablesPlanJSON)
+
+	for _, test := range overriddenVariableTests {
+		violations := overriddenVariableViolations(plan, test.vars)
+		if strings.Join(violations, "\n") != strings.Join(test.expectedViolations, "\n") {
+			t.Errorf("Expected violations %v but got %v", test.expectedViolations, violations)
+		}
+	}
+}